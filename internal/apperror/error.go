@@ -0,0 +1,125 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+type Code string
+
+const (
+	CodeBadRequest      Code = "bad_request"
+	CodeValidation      Code = "validation_failed"
+	CodeUnauthorized    Code = "unauthorized"
+	CodeForbidden       Code = "forbidden"
+	CodeNotFound        Code = "not_found"
+	CodeNotAllowed      Code = "method_not_allowed"
+	CodeConflict        Code = "conflict"
+	CodePrecondition    Code = "precondition_failed"
+	CodeTooManyRequests Code = "too_many_requests"
+	CodeInternal        Code = "internal_error"
+)
+
+var statusByCode = map[Code]int{
+	CodeBadRequest:      http.StatusBadRequest,
+	CodeValidation:      http.StatusBadRequest,
+	CodeUnauthorized:    http.StatusUnauthorized,
+	CodeForbidden:       http.StatusForbidden,
+	CodeNotFound:        http.StatusNotFound,
+	CodeNotAllowed:      http.StatusMethodNotAllowed,
+	CodeConflict:        http.StatusConflict,
+	CodePrecondition:    http.StatusPreconditionFailed,
+	CodeTooManyRequests: http.StatusTooManyRequests,
+	CodeInternal:        http.StatusInternalServerError,
+}
+
+// Error is a domain error that knows how it should be reported to API
+// clients. Services return these (usually as package-level sentinels) and
+// handlers pass them straight to Write.
+type Error struct {
+	Code    Code
+	Message string
+	Details any
+	err     error
+}
+
+func (e *Error) Error() string {
+	if e.err != nil {
+		return e.Message + ": " + e.err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Is matches errors with the same code and message so that a sentinel still
+// matches after being wrapped with a cause or details.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code && t.Message == e.Message
+}
+
+func (e *Error) Status() int {
+	if status, ok := statusByCode[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+func (e *Error) WithDetails(details any) *Error {
+	clone := *e
+	clone.Details = details
+	return &clone
+}
+
+func (e *Error) Wrap(err error) *Error {
+	clone := *e
+	clone.err = err
+	return &clone
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func BadRequest(message string) *Error {
+	return New(CodeBadRequest, message)
+}
+
+func Validation(message string, details any) *Error {
+	return &Error{Code: CodeValidation, Message: message, Details: details}
+}
+
+func Unauthorized(message string) *Error {
+	return New(CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
+func PreconditionFailed(message string) *Error {
+	return New(CodePrecondition, message)
+}
+
+func TooManyRequests(message string) *Error {
+	return New(CodeTooManyRequests, message)
+}
+
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return &Error{Code: CodeInternal, Message: "internal server error", err: err}
+}
@@ -0,0 +1,36 @@
+package apperror
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+type Response struct {
+	Code      Code   `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Write renders err as the standard JSON error envelope. Errors that are not
+// an *Error are reported as internal errors without leaking their text.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	appErr := From(err)
+	requestID := chiMiddleware.GetReqID(r.Context())
+
+	if appErr.Code == CodeInternal {
+		log.Printf("[%s] %s %s: %v", requestID, r.Method, r.URL.Path, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(appErr.Status())
+	json.NewEncoder(w).Encode(Response{
+		Code:      appErr.Code,
+		Message:   appErr.Message,
+		Details:   appErr.Details,
+		RequestID: requestID,
+	})
+}
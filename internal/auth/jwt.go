@@ -1,65 +1,122 @@
 package auth
 
 import (
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	TokenTypeAccess    = "access"
+	TokenTypeRefresh   = "refresh"
+	TokenTypeChallenge = "mfa_challenge"
+)
+
+const (
+	AccessTokenTTL    = 15 * time.Minute
+	RefreshTokenTTL   = 7 * 24 * time.Hour
+	ChallengeTokenTTL = 5 * time.Minute
+)
+
 type JWTService interface {
-	GenerateToken(username, role string) (string, error)
-	GenerateRefreshToken(username string) (string, error)
+	GenerateToken(username, role string, mustChangePassword bool) (string, error)
+	GenerateRefreshToken(username, jti string) (string, error)
 	ValidateToken(tokenString string) (*Claims, error)
 	VerifyToken(tokenString string) (*RefreshClaims, error)
+	GenerateChallengeToken(username string) (string, error)
+	VerifyChallengeToken(tokenString string) (*ChallengeClaims, error)
+	JWKS() JWKSet
 }
 
 type Service struct {
-	secret string
+	active  *Key
+	keys    map[string]*Key
+	methods []string
 }
 
 func NewService(secret string) JWTService {
+	key := &Key{
+		Method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+
 	return &Service{
-		secret: secret,
+		active:  key,
+		keys:    map[string]*Key{"": key},
+		methods: []string{key.Method.Alg()},
+	}
+}
+
+func NewKeyService(keys []*Key, activeKID string) (JWTService, error) {
+	s := &Service{
+		keys: make(map[string]*Key, len(keys)),
+	}
+
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if _, ok := s.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		s.keys[key.ID] = key
+
+		if !seen[key.Method.Alg()] {
+			seen[key.Method.Alg()] = true
+			s.methods = append(s.methods, key.Method.Alg())
+		}
+	}
+
+	active, ok := s.keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found", activeKID)
+	}
+	if !active.CanSign() {
+		return nil, fmt.Errorf("active key %q has no private key", activeKID)
 	}
+	s.active = active
+
+	return s, nil
 }
 
-func (s *Service) GenerateToken(username, role string) (string, error) {
+func (s *Service) GenerateToken(username, role string, mustChangePassword bool) (string, error) {
 	claims := &Claims{
-		Username: username,
-		Role:     role,
+		Username:           username,
+		Role:               role,
+		MustChangePassword: mustChangePassword,
+		TokenType:          TokenTypeAccess,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secret))
+	return s.sign(claims)
 }
 
-func (s *Service) GenerateRefreshToken(username string) (string, error) {
+func (s *Service) GenerateRefreshToken(username, jti string) (string, error) {
 	claims := &RefreshClaims{
-		Username: username,
+		Username:  username,
+		TokenType: TokenTypeRefresh,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secret))
+	return s.sign(claims)
 }
 
 func (s *Service) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (any, error) {
-		return []byte(s.secret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.keyFunc, jwt.WithValidMethods(s.methods))
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid && claims.TokenType == TokenTypeAccess {
 		return claims, nil
 	}
 
@@ -67,28 +124,101 @@ func (s *Service) ValidateToken(tokenString string) (*Claims, error) {
 }
 
 func (s *Service) VerifyToken(tokenString string) (*RefreshClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &RefreshClaims{}, func(token *jwt.Token) (any, error) {
-		return []byte(s.secret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &RefreshClaims{}, s.keyFunc, jwt.WithValidMethods(s.methods))
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*RefreshClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(*RefreshClaims); ok && token.Valid && claims.TokenType == TokenTypeRefresh && claims.ID != "" {
 		return claims, nil
 	}
 
 	return nil, jwt.ErrTokenMalformed
 }
 
+func (s *Service) GenerateChallengeToken(username string) (string, error) {
+	claims := &ChallengeClaims{
+		Username:  username,
+		TokenType: TokenTypeChallenge,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ChallengeTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return s.sign(claims)
+}
+
+func (s *Service) VerifyChallengeToken(tokenString string) (*ChallengeClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ChallengeClaims{}, s.keyFunc, jwt.WithValidMethods(s.methods))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*ChallengeClaims); ok && token.Valid && claims.TokenType == TokenTypeChallenge {
+		return claims, nil
+	}
+
+	return nil, jwt.ErrTokenMalformed
+}
+
+func (s *Service) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range s.keys {
+		if jwk, ok := key.JWK(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+
+	return set
+}
+
+func (s *Service) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.active.Method, claims)
+	if s.active.ID != "" {
+		token.Header["kid"] = s.active.ID
+	}
+
+	return token.SignedString(s.active.signKey)
+}
+
+func (s *Service) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q for key %q", token.Method.Alg(), kid)
+	}
+
+	return key.verifyKey, nil
+}
+
 type Claims struct {
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	Username           string `json:"username"`
+	Role               string `json:"role"`
+	MustChangePassword bool   `json:"must_change_password,omitempty"`
+	TokenType          string `json:"token_type"`
 	jwt.RegisteredClaims
 }
 
 type RefreshClaims struct {
-	Username string `json:"username"`
+	Username  string `json:"username"`
+	TokenType string `json:"token_type"`
+	jwt.RegisteredClaims
+}
+
+type ChallengeClaims struct {
+	Username  string `json:"username"`
+	TokenType string `json:"token_type"`
 	jwt.RegisteredClaims
 }
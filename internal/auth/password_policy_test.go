@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	strict := PasswordPolicy{
+		MinLength:      12,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RequireSymbol:  true,
+		RejectBreached: true,
+	}
+
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		username string
+		want     []string
+	}{
+		{name: "valid", policy: strict, password: "Kereta-Cepat-2024", username: "operator"},
+		{name: "no rules", policy: PasswordPolicy{}, password: "a", username: "operator"},
+		{
+			name:     "too short",
+			policy:   strict,
+			password: "Ab1!",
+			want:     []string{"must be at least 12 characters"},
+		},
+		{
+			name:     "length counts runes",
+			policy:   PasswordPolicy{MinLength: 4},
+			password: "ééé",
+			want:     []string{"must be at least 4 characters"},
+		},
+		{
+			name:     "missing classes",
+			policy:   strict,
+			password: "abcdefghijklmn",
+			want: []string{
+				"must contain an uppercase letter",
+				"must contain a digit",
+				"must contain a symbol",
+			},
+		},
+		{
+			name:     "space is a symbol",
+			policy:   PasswordPolicy{RequireSymbol: true},
+			password: "two words",
+		},
+		{
+			name:     "contains username",
+			policy:   strict,
+			password: "My-OPERATOR-Pass-1",
+			username: "operator",
+			want:     []string{"must not contain the username"},
+		},
+		{
+			name:     "common password",
+			policy:   strict,
+			password: "Password",
+			want: []string{
+				"must be at least 12 characters",
+				"must contain a digit",
+				"must contain a symbol",
+				"is too common",
+			},
+		},
+		{
+			name:     "common password with suffix",
+			policy:   PasswordPolicy{RejectBreached: true},
+			password: "Password123!",
+			want:     []string{"is too common"},
+		},
+		{
+			name:     "common password allowed",
+			policy:   PasswordPolicy{},
+			password: "password",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password, tt.username)
+
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var policyErr *PasswordPolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("Validate() error = %v, want *PasswordPolicyError", err)
+			}
+			if !slices.Equal(policyErr.Violations, tt.want) {
+				t.Errorf("Violations = %q, want %q", policyErr.Violations, tt.want)
+			}
+		})
+	}
+}
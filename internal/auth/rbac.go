@@ -0,0 +1,78 @@
+package auth
+
+const (
+	RoleSuperAdmin = "superadmin"
+	RoleOperator   = "operator"
+	RoleFinance    = "finance"
+	RoleSupport    = "support"
+	RoleViewer     = "viewer"
+)
+
+const (
+	PermTerminalsRead    = "terminals:read"
+	PermTerminalsWrite   = "terminals:write"
+	PermFaresRead        = "fares:read"
+	PermFaresWrite       = "fares:write"
+	PermCardsRead        = "cards:read"
+	PermCardsWrite       = "cards:write"
+	PermHoldersRead      = "holders:read"
+	PermTransactionsRead = "transactions:read"
+	PermAdminsRead       = "admins:read"
+	PermAdminsWrite      = "admins:write"
+	PermAuditRead        = "audit:read"
+	PermAlertsRead       = "alerts:read"
+)
+
+var rolePermissions = map[string][]string{
+	RoleSuperAdmin: {
+		PermTerminalsRead, PermTerminalsWrite,
+		PermFaresRead, PermFaresWrite,
+		PermCardsRead, PermCardsWrite,
+		PermHoldersRead,
+		PermTransactionsRead,
+		PermAdminsRead, PermAdminsWrite,
+		PermAuditRead,
+		PermAlertsRead,
+	},
+	RoleOperator: {
+		PermTerminalsRead, PermTerminalsWrite,
+		PermFaresRead,
+		PermCardsRead,
+		PermTransactionsRead,
+		PermAlertsRead,
+	},
+	RoleFinance: {
+		PermTerminalsRead,
+		PermFaresRead, PermFaresWrite,
+		PermCardsRead,
+		PermTransactionsRead,
+	},
+	RoleSupport: {
+		PermTerminalsRead,
+		PermFaresRead,
+		PermCardsRead, PermCardsWrite,
+		PermHoldersRead,
+		PermTransactionsRead,
+		PermAlertsRead,
+	},
+	RoleViewer: {
+		PermTerminalsRead,
+		PermFaresRead,
+		PermCardsRead,
+		PermTransactionsRead,
+	},
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func HasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 test key of RFC 6238, "12345678901234567890".
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		at       int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "rfc vector 59", secret: rfc6238Secret, code: "287082", at: 59, wantStep: 1, wantOK: true},
+		{name: "rfc vector 1111111109", secret: rfc6238Secret, code: "081804", at: 1111111109, wantStep: 37037036, wantOK: true},
+		{name: "rfc vector 1234567890", secret: rfc6238Secret, code: "005924", at: 1234567890, wantStep: 41152263, wantOK: true},
+		{name: "rfc vector 2000000000", secret: rfc6238Secret, code: "279037", at: 2000000000, wantStep: 66666666, wantOK: true},
+		{name: "lowercase secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code: "287082", at: 59, wantStep: 1, wantOK: true},
+		{name: "previous step within skew", secret: rfc6238Secret, code: "287082", at: 89, wantStep: 1, wantOK: true},
+		{name: "next step within skew", secret: rfc6238Secret, code: "081804", at: 1111111109 - 30, wantStep: 37037036, wantOK: true},
+		{name: "outside skew", secret: rfc6238Secret, code: "287082", at: 120},
+		{name: "wrong code", secret: rfc6238Secret, code: "287083", at: 59},
+		{name: "short code", secret: rfc6238Secret, code: "28708", at: 59},
+		{name: "invalid secret", secret: "not base32!", code: "287082", at: 59},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, time.Unix(tt.at, 0))
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("ValidateTOTP() = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not base32: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("key length = %d, want 20", len(key))
+	}
+
+	code := totpCode(key, time.Now().Unix()/totpPeriod)
+	if _, ok := ValidateTOTP(secret, code, time.Now()); !ok {
+		t.Errorf("ValidateTOTP() rejected the current code %s", code)
+	}
+}
@@ -0,0 +1,106 @@
+// Package bloom implements the Bloom filter exported to gate readers so they
+// can check blocklisted card numbers without a network round trip. The
+// binary layout is documented on MarshalBinary so readers can be written
+// without this package.
+package bloom
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+)
+
+const (
+	magic         = "HLBF"
+	formatVersion = 1
+	headerSize    = 16
+
+	minBits = 64
+)
+
+type Filter struct {
+	bits []byte
+	m    uint32
+	k    uint8
+	n    uint32
+}
+
+// New sizes a filter for n keys at the given false positive rate.
+func New(n int, falsePositiveRate float64) *Filter {
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.001
+	}
+
+	m := minBits
+	if n > 0 {
+		m = int(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+		m = max(m, minBits)
+	}
+	m = min(m, math.MaxUint32)
+
+	k := 1
+	if n > 0 {
+		k = int(math.Round(float64(m) / float64(n) * math.Ln2))
+		k = min(max(k, 1), math.MaxUint8)
+	}
+
+	return &Filter{
+		bits: make([]byte, (m+7)/8),
+		m:    uint32(m),
+		k:    uint8(k),
+	}
+}
+
+func (f *Filter) Add(key string) {
+	h1, h2 := hashes(key)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		bit := (h1 + i*h2) % uint64(f.m)
+		f.bits[bit/8] |= 1 << (bit % 8)
+	}
+	f.n++
+}
+
+// Test reports whether key may be in the set. False positives are possible,
+// false negatives are not.
+func (f *Filter) Test(key string) bool {
+	h1, h2 := hashes(key)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		bit := (h1 + i*h2) % uint64(f.m)
+		if f.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalBinary encodes the filter as a 16 byte big-endian header followed
+// by the bit array:
+//
+//	0  magic "HLBF"
+//	4  format version (1)
+//	5  k, number of hash functions
+//	6  reserved (2 bytes, zero)
+//	8  m, number of bits (uint32)
+//	12 n, number of keys added (uint32)
+//	16 bit array, ceil(m/8) bytes; bit i is byte i/8, mask 1<<(i%8)
+//
+// Bit positions for a key are (h1 + i*h2) mod m for i in [0, k), where h is
+// the 64-bit FNV-1a hash of the key bytes, h1 its low 32 bits and h2 its high
+// 32 bits with the lowest bit forced to 1, all computed in uint64.
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	out := make([]byte, headerSize+len(f.bits))
+	copy(out, magic)
+	out[4] = formatVersion
+	out[5] = f.k
+	binary.BigEndian.PutUint32(out[8:], f.m)
+	binary.BigEndian.PutUint32(out[12:], f.n)
+	copy(out[headerSize:], f.bits)
+	return out, nil
+}
+
+func hashes(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	return sum & math.MaxUint32, (sum >> 32) | 1
+}
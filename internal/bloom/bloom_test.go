@@ -0,0 +1,116 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name              string
+		keys              int
+		falsePositiveRate float64
+		wantK             uint8
+	}{
+		{name: "empty", keys: 0, falsePositiveRate: 0.001, wantK: 1},
+		{name: "one key", keys: 1, falsePositiveRate: 0.001, wantK: 44},
+		{name: "thousand keys", keys: 1000, falsePositiveRate: 0.001, wantK: 10},
+		{name: "invalid rate falls back", keys: 1000, falsePositiveRate: 1.5, wantK: 10},
+		{name: "loose rate", keys: 1000, falsePositiveRate: 0.1, wantK: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := New(tt.keys, tt.falsePositiveRate)
+			if filter.k != tt.wantK {
+				t.Errorf("k = %d, want %d", filter.k, tt.wantK)
+			}
+			if filter.m < minBits {
+				t.Errorf("m = %d, want at least %d", filter.m, minBits)
+			}
+
+			for i := range tt.keys {
+				filter.Add(cardNumber(i))
+			}
+
+			for i := range tt.keys {
+				if !filter.Test(cardNumber(i)) {
+					t.Fatalf("Test(%s) = false for an added key", cardNumber(i))
+				}
+			}
+		})
+	}
+}
+
+func TestFilterFalsePositiveRate(t *testing.T) {
+	const keys, probes, rate = 10000, 100000, 0.01
+
+	filter := New(keys, rate)
+	for i := range keys {
+		filter.Add(cardNumber(i))
+	}
+
+	falsePositives := 0
+	for i := keys; i < keys+probes; i++ {
+		if filter.Test(cardNumber(i)) {
+			falsePositives++
+		}
+	}
+
+	// Allow twice the configured rate for sampling noise.
+	if got := float64(falsePositives) / probes; got > 2*rate {
+		t.Errorf("false positive rate = %.4f, want at most %.4f", got, 2*rate)
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	filter := New(100, 0.01)
+	filter.Add("1234567890123456")
+	filter.Add("2345678901234567")
+
+	data, err := filter.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+
+	if got := string(data[0:4]); got != magic {
+		t.Errorf("magic = %q, want %q", got, magic)
+	}
+	if data[4] != formatVersion {
+		t.Errorf("format version = %d, want %d", data[4], formatVersion)
+	}
+	if data[5] != filter.k {
+		t.Errorf("k = %d, want %d", data[5], filter.k)
+	}
+	if data[6] != 0 || data[7] != 0 {
+		t.Errorf("reserved = %v, want zero", data[6:8])
+	}
+
+	m := binary.BigEndian.Uint32(data[8:])
+	if m != filter.m {
+		t.Errorf("m = %d, want %d", m, filter.m)
+	}
+	if n := binary.BigEndian.Uint32(data[12:]); n != 2 {
+		t.Errorf("n = %d, want 2", n)
+	}
+	if want := headerSize + int(m+7)/8; len(data) != want {
+		t.Errorf("length = %d, want %d", len(data), want)
+	}
+
+	// A reader following the documented layout finds the same bits.
+	bits := data[headerSize:]
+	for _, key := range []string{"1234567890123456", "2345678901234567"} {
+		h1, h2 := hashes(key)
+		for i := uint64(0); i < uint64(data[5]); i++ {
+			bit := (h1 + i*h2) % uint64(m)
+			if bits[bit/8]&(1<<(bit%8)) == 0 {
+				t.Errorf("bit %d for %s is not set", bit, key)
+			}
+		}
+	}
+}
+
+func cardNumber(i int) string {
+	return fmt.Sprintf("%016d", i)
+}
@@ -1,21 +1,244 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/money"
+)
+
+const (
+	FareModelMatrix = "matrix"
+	FareModelZone   = "zone"
 )
 
+const (
+	NotifierLog     = "log"
+	NotifierWebhook = "webhook"
+)
+
+// minSecretLength is the shortest secret accepted, 32 bytes being the size of
+// the HMAC-SHA256 key the secrets are used as.
+const minSecretLength = 32
+
+// placeholderSecrets are example values from earlier releases and
+// .env.example, which are public and must never sign or key anything.
+var placeholderSecrets = map[string]bool{
+	"your-secret-key-here": true,
+	"super-secret-jwt-key": true,
+
+	"your-holder-hash-key-here": true,
+}
+
 type Config struct {
-	Port        string
-	DatabaseURL string
-	JWTSecret   string
+	Port         string
+	DatabaseURL  string
+	JWTSecret    string
+	JWTKeyDir    string
+	JWTActiveKID string
+
+	LoginMaxAttempts      int
+	LoginMaxAttemptsPerIP int
+	LoginLockoutDuration  time.Duration
+
+	TOTPIssuer string
+
+	PasswordMinLength      int
+	PasswordRequireUpper   bool
+	PasswordRequireLower   bool
+	PasswordRequireDigit   bool
+	PasswordRequireSymbol  bool
+	PasswordRejectBreached bool
+
+	Currency money.Currency
+	Locale   money.Locale
+
+	FareModel      string
+	FareBaseAmount money.Amount
+	FarePerKmRate  money.Amount
+	FareRounding   money.Amount
+
+	ServiceLocation  *time.Location
+	TapInGracePeriod time.Duration
+
+	HotlistFalsePositiveRate float64
+
+	CardValidityYears  int
+	CardExpiryInterval time.Duration
+
+	CardHolderHashKey string
+
+	AlertInterval          time.Duration
+	AlertLowBalance        money.Amount
+	AlertFailedTapLimit    int
+	AlertFailedTapWindow   time.Duration
+	AlertMaxTravelSpeedKmh float64
+	AlertNotifier          string
+	AlertWebhookURL        string
+	AlertWebhookTimeout    time.Duration
 }
 
-func Load() *Config {
-	return &Config{
-		Port:        getEnv("PORT", "8080"),
-		DatabaseURL: getEnv("DATABASE_URL", "postgres://localhost:5432/eticket_transport?sslmode=disable"),
-		JWTSecret:   getEnv("JWT_SECRET", "your-secret-key-here"),
+// Load reads the configuration from the environment and rejects settings the
+// service cannot run with, so a typo fails at startup instead of falling back
+// to a default. Unset or empty variables take their default.
+func Load() (*Config, error) {
+	var env envReader
+
+	cfg := &Config{
+		Port:         getEnv("PORT", "8080"),
+		DatabaseURL:  getEnv("DATABASE_URL", "postgres://localhost:5432/eticket_transport?sslmode=disable"),
+		JWTSecret:    getEnv("JWT_SECRET", ""),
+		JWTKeyDir:    getEnv("JWT_KEY_DIR", ""),
+		JWTActiveKID: getEnv("JWT_ACTIVE_KID", ""),
+
+		LoginMaxAttempts:      env.Int("LOGIN_MAX_ATTEMPTS", 5),
+		LoginMaxAttemptsPerIP: env.Int("LOGIN_MAX_ATTEMPTS_PER_IP", 20),
+		LoginLockoutDuration:  env.Duration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+
+		TOTPIssuer: getEnv("TOTP_ISSUER", "MKP E-Ticket"),
+
+		PasswordMinLength:      env.Int("PASSWORD_MIN_LENGTH", 12),
+		PasswordRequireUpper:   env.Bool("PASSWORD_REQUIRE_UPPER", true),
+		PasswordRequireLower:   env.Bool("PASSWORD_REQUIRE_LOWER", true),
+		PasswordRequireDigit:   env.Bool("PASSWORD_REQUIRE_DIGIT", true),
+		PasswordRequireSymbol:  env.Bool("PASSWORD_REQUIRE_SYMBOL", false),
+		PasswordRejectBreached: env.Bool("PASSWORD_REJECT_BREACHED", true),
+
+		FareModel:      getEnv("FARE_MODEL", FareModelMatrix),
+		FareBaseAmount: env.Money("FARE_BASE_AMOUNT", money.FromMinor(300000)),
+		FarePerKmRate:  env.Money("FARE_PER_KM_RATE", money.FromMinor(50000)),
+		FareRounding:   env.Money("FARE_ROUNDING", money.FromMinor(50000)),
+
+		TapInGracePeriod: env.Duration("TAP_IN_GRACE_PERIOD", 15*time.Minute),
+
+		HotlistFalsePositiveRate: env.Float("HOTLIST_FALSE_POSITIVE_RATE", 0.001),
+
+		CardValidityYears:  env.Int("CARD_VALIDITY_YEARS", 2),
+		CardExpiryInterval: env.Duration("CARD_EXPIRY_INTERVAL", time.Hour),
+
+		CardHolderHashKey: getEnv("CARD_HOLDER_HASH_KEY", ""),
+
+		AlertInterval:          env.Duration("ALERT_INTERVAL", 30*time.Second),
+		AlertLowBalance:        env.Money("ALERT_LOW_BALANCE", money.FromMinor(500000)),
+		AlertFailedTapLimit:    env.Int("ALERT_FAILED_TAP_LIMIT", 3),
+		AlertFailedTapWindow:   env.Duration("ALERT_FAILED_TAP_WINDOW", 5*time.Minute),
+		AlertMaxTravelSpeedKmh: env.Float("ALERT_MAX_TRAVEL_SPEED_KMH", 80),
+		AlertNotifier:          getEnv("ALERT_NOTIFIER", NotifierLog),
+		AlertWebhookURL:        getEnv("ALERT_WEBHOOK_URL", ""),
+		AlertWebhookTimeout:    env.Duration("ALERT_WEBHOOK_TIMEOUT", 5*time.Second),
+	}
+
+	if err := env.Err(); err != nil {
+		return nil, err
+	}
+
+	currency, ok := money.LookupCurrency(getEnv("CURRENCY", money.IDR.Code))
+	if !ok {
+		return nil, fmt.Errorf("unknown CURRENCY %q", os.Getenv("CURRENCY"))
 	}
+	cfg.Currency = currency
+
+	locale, ok := money.LookupLocale(getEnv("LOCALE", money.LocaleID.Tag))
+	if !ok {
+		return nil, fmt.Errorf("unknown LOCALE %q", os.Getenv("LOCALE"))
+	}
+	cfg.Locale = locale
+
+	location, err := time.LoadLocation(getEnv("SERVICE_TIMEZONE", "Asia/Jakarta"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SERVICE_TIMEZONE: %w", err)
+	}
+	cfg.ServiceLocation = location
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) validate() error {
+	// Tokens are signed with JWT_SECRET unless asymmetric keys are configured.
+	if c.JWTKeyDir == "" {
+		if err := checkSecret("JWT_SECRET", c.JWTSecret); err != nil {
+			return fmt.Errorf("%w (or set JWT_KEY_DIR)", err)
+		}
+	}
+
+	// A known key would let anyone hash every possible identity number and
+	// read them back from card_holders.
+	if err := checkSecret("CARD_HOLDER_HASH_KEY", c.CardHolderHashKey); err != nil {
+		return err
+	}
+
+	if c.FareModel != FareModelMatrix && c.FareModel != FareModelZone {
+		return fmt.Errorf("FARE_MODEL must be %q or %q, got %q", FareModelMatrix, FareModelZone, c.FareModel)
+	}
+
+	switch c.AlertNotifier {
+	case NotifierLog:
+	case NotifierWebhook:
+		u, err := url.Parse(c.AlertWebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("ALERT_WEBHOOK_URL must be an http or https URL when ALERT_NOTIFIER is %q", NotifierWebhook)
+		}
+	default:
+		return fmt.Errorf("ALERT_NOTIFIER must be %q or %q, got %q", NotifierLog, NotifierWebhook, c.AlertNotifier)
+	}
+
+	return c.validateRanges()
+}
+
+func checkSecret(key, value string) error {
+	switch {
+	case value == "":
+		return fmt.Errorf("%s is required", key)
+	case placeholderSecrets[value]:
+		return fmt.Errorf("%s is set to a published example value", key)
+	case len(value) < minSecretLength:
+		return fmt.Errorf("%s must be at least %d bytes, got %d", key, minSecretLength, len(value))
+	}
+	return nil
+}
+
+// validateRanges rejects numeric settings outside the values the service can
+// work with. Intervals may be zero where zero disables the job.
+func (c *Config) validateRanges() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.LoginMaxAttempts > 0, "LOGIN_MAX_ATTEMPTS must be positive, got %d", c.LoginMaxAttempts)
+	check(c.LoginMaxAttemptsPerIP > 0, "LOGIN_MAX_ATTEMPTS_PER_IP must be positive, got %d", c.LoginMaxAttemptsPerIP)
+	check(c.LoginLockoutDuration > 0, "LOGIN_LOCKOUT_DURATION must be positive, got %s", c.LoginLockoutDuration)
+	check(c.PasswordMinLength > 0, "PASSWORD_MIN_LENGTH must be positive, got %d", c.PasswordMinLength)
+
+	check(c.FareBaseAmount >= 0, "FARE_BASE_AMOUNT must not be negative, got %s", c.FareBaseAmount)
+	check(c.FarePerKmRate >= 0, "FARE_PER_KM_RATE must not be negative, got %s", c.FarePerKmRate)
+	check(c.FareRounding >= 0, "FARE_ROUNDING must not be negative, got %s", c.FareRounding)
+
+	check(c.TapInGracePeriod >= 0, "TAP_IN_GRACE_PERIOD must not be negative, got %s", c.TapInGracePeriod)
+	check(c.HotlistFalsePositiveRate > 0 && c.HotlistFalsePositiveRate < 1,
+		"HOTLIST_FALSE_POSITIVE_RATE must be between 0 and 1, got %g", c.HotlistFalsePositiveRate)
+
+	check(c.CardValidityYears > 0, "CARD_VALIDITY_YEARS must be positive, got %d", c.CardValidityYears)
+	check(c.CardExpiryInterval >= 0, "CARD_EXPIRY_INTERVAL must not be negative, got %s", c.CardExpiryInterval)
+
+	check(c.AlertInterval >= 0, "ALERT_INTERVAL must not be negative, got %s", c.AlertInterval)
+	check(c.AlertLowBalance >= 0, "ALERT_LOW_BALANCE must not be negative, got %s", c.AlertLowBalance)
+	check(c.AlertFailedTapLimit >= 0, "ALERT_FAILED_TAP_LIMIT must not be negative, got %d", c.AlertFailedTapLimit)
+	check(c.AlertFailedTapWindow > 0, "ALERT_FAILED_TAP_WINDOW must be positive, got %s", c.AlertFailedTapWindow)
+	check(c.AlertMaxTravelSpeedKmh >= 0, "ALERT_MAX_TRAVEL_SPEED_KMH must not be negative, got %g", c.AlertMaxTravelSpeedKmh)
+	check(c.AlertWebhookTimeout > 0, "ALERT_WEBHOOK_TIMEOUT must be positive, got %s", c.AlertWebhookTimeout)
+
+	return errors.Join(errs...)
 }
 
 func getEnv(key, defaultValue string) string {
@@ -24,3 +247,87 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// envReader parses typed environment variables, collecting every malformed
+// value so that Load reports them together.
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) Err() error {
+	return errors.Join(e.errs...)
+}
+
+func (e *envReader) fail(key, value string, err error) {
+	e.errs = append(e.errs, fmt.Errorf("invalid %s %q: %w", key, value, err))
+}
+
+func (e *envReader) Int(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		e.fail(key, value, err)
+		return defaultValue
+	}
+	return parsed
+}
+
+func (e *envReader) Float(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		e.fail(key, value, err)
+		return defaultValue
+	}
+	return parsed
+}
+
+func (e *envReader) Money(key string, defaultValue money.Amount) money.Amount {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := money.Parse(value)
+	if err != nil {
+		e.fail(key, value, err)
+		return defaultValue
+	}
+	return parsed
+}
+
+func (e *envReader) Bool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		e.fail(key, value, err)
+		return defaultValue
+	}
+	return parsed
+}
+
+func (e *envReader) Duration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		e.fail(key, value, err)
+		return defaultValue
+	}
+	return parsed
+}
@@ -0,0 +1,63 @@
+package config
+
+import "testing"
+
+// validEnv holds the settings that have no usable default.
+var validEnv = map[string]string{
+	"JWT_SECRET":           "0123456789abcdef0123456789abcdef",
+	"CARD_HOLDER_HASH_KEY": "fedcba9876543210fedcba9876543210",
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{name: "defaults"},
+		{name: "zone fare model", env: map[string]string{"FARE_MODEL": "zone"}},
+		{name: "unknown fare model", env: map[string]string{"FARE_MODEL": "zones"}, wantErr: true},
+		{name: "service timezone", env: map[string]string{"SERVICE_TIMEZONE": "Asia/Makassar"}},
+		{name: "currency and locale", env: map[string]string{"CURRENCY": "usd", "LOCALE": "en_US"}},
+		{name: "unknown currency", env: map[string]string{"CURRENCY": "EUR"}, wantErr: true},
+		{name: "unknown locale", env: map[string]string{"LOCALE": "fr-FR"}, wantErr: true},
+		{name: "unknown service timezone", env: map[string]string{"SERVICE_TIMEZONE": "Asia/Bandung"}, wantErr: true},
+		{name: "webhook notifier", env: map[string]string{"ALERT_NOTIFIER": "webhook", "ALERT_WEBHOOK_URL": "https://alerts.example.com/hook"}},
+		{name: "unknown notifier", env: map[string]string{"ALERT_NOTIFIER": "slack"}, wantErr: true},
+		{name: "webhook without URL", env: map[string]string{"ALERT_NOTIFIER": "webhook"}, wantErr: true},
+		{name: "malformed integer", env: map[string]string{"LOGIN_MAX_ATTEMPTS": "abc"}, wantErr: true},
+		{name: "malformed amount", env: map[string]string{"ALERT_LOW_BALANCE": "5.5x"}, wantErr: true},
+		{name: "duration without unit", env: map[string]string{"TAP_IN_GRACE_PERIOD": "15"}, wantErr: true},
+		{name: "malformed bool", env: map[string]string{"PASSWORD_REQUIRE_UPPER": "yes"}, wantErr: true},
+		{name: "malformed float", env: map[string]string{"ALERT_MAX_TRAVEL_SPEED_KMH": "fast"}, wantErr: true},
+		{name: "false positive rate of one", env: map[string]string{"HOTLIST_FALSE_POSITIVE_RATE": "1"}, wantErr: true},
+		{name: "zero false positive rate", env: map[string]string{"HOTLIST_FALSE_POSITIVE_RATE": "0"}, wantErr: true},
+		{name: "zero card validity", env: map[string]string{"CARD_VALIDITY_YEARS": "0"}, wantErr: true},
+		{name: "negative fare", env: map[string]string{"FARE_BASE_AMOUNT": "-1"}, wantErr: true},
+		{name: "disabled jobs", env: map[string]string{"ALERT_INTERVAL": "0s", "CARD_EXPIRY_INTERVAL": "0s", "ALERT_FAILED_TAP_LIMIT": "0"}},
+		{name: "missing JWT secret", env: map[string]string{"JWT_SECRET": ""}, wantErr: true},
+		{name: "placeholder JWT secret", env: map[string]string{"JWT_SECRET": "your-secret-key-here"}, wantErr: true},
+		{name: "short JWT secret", env: map[string]string{"JWT_SECRET": "too-short"}, wantErr: true},
+		{name: "key directory instead of a secret", env: map[string]string{"JWT_SECRET": "", "JWT_KEY_DIR": "./keys"}},
+		{name: "missing holder hash key", env: map[string]string{"CARD_HOLDER_HASH_KEY": ""}, wantErr: true},
+		{name: "placeholder holder hash key", env: map[string]string{"CARD_HOLDER_HASH_KEY": "your-holder-hash-key-here"}, wantErr: true},
+		{name: "short holder hash key", env: map[string]string{"CARD_HOLDER_HASH_KEY": "0123456789"}, wantErr: true},
+		{name: "webhook with relative URL", env: map[string]string{"ALERT_NOTIFIER": "webhook", "ALERT_WEBHOOK_URL": "alerts/hook"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range validEnv {
+				t.Setenv(key, value)
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package geo
+
+import "math"
+
+const earthRadiusKm = 6371.0
+
+// DistanceKm returns the great-circle distance between two coordinates in
+// kilometres using the haversine formula.
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := radians(lat2 - lat1)
+	dLng := radians(lng2 - lng1)
+
+	a := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(radians(lat1))*math.Cos(radians(lat2))*math.Pow(math.Sin(dLng/2), 2)
+
+	// Rounding can push a just past 1 for near-antipodal points, where Asin
+	// would return NaN.
+	return earthRadiusKm * 2 * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Box is a latitude/longitude range in degrees.
+type Box struct {
+	MinLat, MaxLat float64
+	MinLng, MaxLng float64
+}
+
+// BoundingBox returns a box containing every point within radiusKm of the
+// coordinate. Near the poles and across the antimeridian the box spans every
+// longitude rather than wrapping around.
+func BoundingBox(lat, lng, radiusKm float64) Box {
+	distance := radiusKm / earthRadiusKm
+	dLat := degrees(distance)
+
+	box := Box{
+		MinLat: math.Max(-90, lat-dLat),
+		MaxLat: math.Min(90, lat+dLat),
+		MinLng: -180,
+		MaxLng: 180,
+	}
+
+	if box.MinLat == -90 || box.MaxLat == 90 {
+		return box
+	}
+
+	ratio := math.Sin(distance) / math.Cos(radians(lat))
+	if ratio >= 1 {
+		return box
+	}
+
+	dLng := degrees(math.Asin(ratio))
+	if lng-dLng < -180 || lng+dLng > 180 {
+		return box
+	}
+
+	box.MinLng, box.MaxLng = lng-dLng, lng+dLng
+	return box
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
@@ -0,0 +1,97 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lng1, lat2, lng2 float64
+		want                   float64
+	}{
+		{name: "same point", lat1: -6.2, lng1: 106.8, lat2: -6.2, lng2: 106.8, want: 0},
+		{name: "one degree along the equator", lat1: 0, lng1: 0, lat2: 0, lng2: 1, want: 111.195},
+		{name: "one degree along a meridian", lat1: 0, lng1: 0, lat2: 1, lng2: 0, want: 111.195},
+		{name: "antipodal", lat1: 0, lng1: 0, lat2: 0, lng2: 180, want: 20015.087},
+		{name: "near antipodal", lat1: 45, lng1: 30, lat2: -45, lng2: -150, want: 20015.087},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DistanceKm(tt.lat1, tt.lng1, tt.lat2, tt.lng2)
+			if math.IsNaN(got) || math.Abs(got-tt.want) > 0.001 {
+				t.Errorf("DistanceKm() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lng float64
+		radiusKm float64
+		want     Box
+	}{
+		{
+			name: "equator",
+			lat:  0, lng: 0, radiusKm: 111.195,
+			want: Box{MinLat: -1, MaxLat: 1, MinLng: -1, MaxLng: 1},
+		},
+		{
+			name: "reaches the pole",
+			lat:  89.5, lng: 10, radiusKm: 111.195,
+			want: Box{MinLat: 88.5, MaxLat: 90, MinLng: -180, MaxLng: 180},
+		},
+		{
+			name: "crosses the antimeridian",
+			lat:  0, lng: 179.5, radiusKm: 111.195,
+			want: Box{MinLat: -1, MaxLat: 1, MinLng: -180, MaxLng: 180},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BoundingBox(tt.lat, tt.lng, tt.radiusKm)
+			if !sameBox(got, tt.want) {
+				t.Errorf("BoundingBox() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBoundingBoxContainsRadius checks that every point on the radius around
+// a mid-latitude coordinate falls inside its box.
+func TestBoundingBoxContainsRadius(t *testing.T) {
+	lat, lng, radiusKm := -6.2, 106.8, 25.0
+	box := BoundingBox(lat, lng, radiusKm)
+
+	for bearing := 0.0; bearing < 360; bearing += 5 {
+		pLat, pLng := destination(lat, lng, bearing, radiusKm*0.999)
+		if pLat < box.MinLat || pLat > box.MaxLat || pLng < box.MinLng || pLng > box.MaxLng {
+			t.Errorf("point (%v, %v) at bearing %v is outside %+v", pLat, pLng, bearing, box)
+		}
+	}
+}
+
+func sameBox(a, b Box) bool {
+	const tolerance = 1e-3
+	return math.Abs(a.MinLat-b.MinLat) < tolerance &&
+		math.Abs(a.MaxLat-b.MaxLat) < tolerance &&
+		math.Abs(a.MinLng-b.MinLng) < tolerance &&
+		math.Abs(a.MaxLng-b.MaxLng) < tolerance
+}
+
+// destination returns the point distanceKm from the coordinate along the
+// initial bearing in degrees.
+func destination(lat, lng, bearing, distanceKm float64) (float64, float64) {
+	d := distanceKm / earthRadiusKm
+	lat1, lng1, brng := radians(lat), radians(lng), radians(bearing)
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(brng))
+	lng2 := lng1 + math.Atan2(math.Sin(brng)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+
+	return degrees(lat2), degrees(lng2)
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/middleware"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// actorFrom returns the authenticated admin making the request, as the
+// services record it in the audit log.
+func actorFrom(r *http.Request) *model.Actor {
+	ctx := r.Context()
+
+	username, _ := middleware.UsernameFromContext(ctx)
+	role, _ := middleware.RoleFromContext(ctx)
+	ip, _ := middleware.ClientIPFromContext(ctx)
+
+	return &model.Actor{
+		Username:  username,
+		Role:      role,
+		IPAddress: ip,
+		RequestID: chiMiddleware.GetReqID(ctx),
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/middleware"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/validator"
@@ -12,7 +14,12 @@ import (
 )
 
 type AdminHandler interface {
+	List(w http.ResponseWriter, r *http.Request)
+	FindByID(w http.ResponseWriter, r *http.Request)
 	Create(w http.ResponseWriter, r *http.Request)
+	Update(w http.ResponseWriter, r *http.Request)
+	ChangePassword(w http.ResponseWriter, r *http.Request)
+	Unlock(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
 }
 
@@ -24,26 +31,59 @@ func NewAdminHandler(service service.AdminService) AdminHandler {
 	return &adminHandler{service: service}
 }
 
+func (h *adminHandler) List(w http.ResponseWriter, r *http.Request) {
+	admins, err := h.service.List(r.Context())
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": admins,
+	})
+}
+
+func (h *adminHandler) FindByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	admin, err := h.service.FindByID(r.Context(), id)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errAdminNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": admin,
+	})
+}
+
 func (h *adminHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req model.CreateAdminRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperror.Write(w, r, errInvalidBody)
 		return
 	}
 
 	if err := validator.ValidateStruct(req); err != nil {
-		validator.HandleValidationError(w, err)
+		apperror.Write(w, r, err)
 		return
 	}
 
 	admin := &model.Admin{
 		Username: req.Username,
 		Password: req.Password,
+		Role:     req.Role,
 	}
 
-	err := h.service.Create(r.Context(), admin)
+	err := h.service.Create(r.Context(), actorFrom(r), admin)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, r, err)
 		return
 	}
 
@@ -54,16 +94,88 @@ func (h *adminHandler) Create(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *adminHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	var req model.UpdateAdminRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	admin, err := h.service.Update(r.Context(), actorFrom(r), id, &req)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errAdminNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": admin,
+	})
+}
+
+func (h *adminHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	if _, ok := middleware.UsernameFromContext(r.Context()); !ok {
+		apperror.Write(w, r, errUnauthenticated)
+		return
+	}
+
+	var req model.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	err := h.service.ChangePassword(r.Context(), actorFrom(r), &req)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *adminHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	err = h.service.Unlock(r.Context(), actorFrom(r), id)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errAdminNotFound))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *adminHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		apperror.Write(w, r, errInvalidID)
 		return
 	}
 
-	err = h.service.Delete(r.Context(), id)
+	err = h.service.Delete(r.Context(), actorFrom(r), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, r, notFoundAs(err, errAdminNotFound))
 		return
 	}
 
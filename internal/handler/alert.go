@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/validator"
+	"github.com/google/uuid"
+)
+
+type AlertHandler interface {
+	List(w http.ResponseWriter, r *http.Request)
+}
+
+type alertHandler struct {
+	service service.AlertService
+}
+
+func NewAlertHandler(service service.AlertService) AlertHandler {
+	return &alertHandler{service: service}
+}
+
+func (h *alertHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := model.AlertFilter{
+		AlertType: query.Get("alert_type"),
+		Limit:     50,
+	}
+
+	if err := queryInt(query, "limit", &filter.Limit); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+	if err := queryInt(query, "offset", &filter.Offset); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+	if value := query.Get("card_id"); value != "" {
+		cardID, err := uuid.Parse(value)
+		if err != nil {
+			apperror.Write(w, r, apperror.BadRequest("invalid card_id"))
+			return
+		}
+		filter.CardID = &cardID
+	}
+	if value := query.Get("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			apperror.Write(w, r, apperror.BadRequest("invalid from, expected RFC3339"))
+			return
+		}
+		filter.From = &from
+	}
+	if value := query.Get("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			apperror.Write(w, r, apperror.BadRequest("invalid to, expected RFC3339"))
+			return
+		}
+		filter.To = &to
+	}
+
+	if err := validator.ValidateStruct(filter); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	alerts, err := h.service.List(r.Context(), &filter)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": alerts,
+	})
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/validator"
+)
+
+type AuditHandler interface {
+	List(w http.ResponseWriter, r *http.Request)
+}
+
+type auditHandler struct {
+	service service.AuditService
+}
+
+func NewAuditHandler(service service.AuditService) AuditHandler {
+	return &auditHandler{service: service}
+}
+
+func (h *auditHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := model.AuditLogFilter{
+		Actor:      query.Get("actor"),
+		Action:     query.Get("action"),
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+		Limit:      50,
+	}
+
+	var err error
+	if value := query.Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil {
+			apperror.Write(w, r, apperror.BadRequest("invalid limit"))
+			return
+		}
+	}
+	if value := query.Get("offset"); value != "" {
+		if filter.Offset, err = strconv.Atoi(value); err != nil {
+			apperror.Write(w, r, apperror.BadRequest("invalid offset"))
+			return
+		}
+	}
+	if value := query.Get("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			apperror.Write(w, r, apperror.BadRequest("invalid from, expected RFC3339"))
+			return
+		}
+		filter.From = &from
+	}
+	if value := query.Get("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			apperror.Write(w, r, apperror.BadRequest("invalid to, expected RFC3339"))
+			return
+		}
+		filter.To = &to
+	}
+
+	if err := validator.ValidateStruct(filter); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	logs, err := h.service.List(r.Context(), &filter)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": logs,
+	})
+}
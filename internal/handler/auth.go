@@ -2,10 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/auth"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/middleware"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/validator"
@@ -13,59 +17,121 @@ import (
 
 type AuthHandler interface {
 	Login(w http.ResponseWriter, r *http.Request)
+	LoginTOTP(w http.ResponseWriter, r *http.Request)
 	RefreshToken(w http.ResponseWriter, r *http.Request)
+	Logout(w http.ResponseWriter, r *http.Request)
 }
 
 type authHandler struct {
-	adminService service.AdminService
-	jwtService   auth.JWTService
+	adminService        service.AdminService
+	refreshTokenService service.RefreshTokenService
+	totpService         service.TOTPService
+	jwtService          auth.JWTService
 }
 
-func NewAuthHandler(adminService service.AdminService, jwtService auth.JWTService) AuthHandler {
+func NewAuthHandler(adminService service.AdminService, refreshTokenService service.RefreshTokenService, totpService service.TOTPService, jwtService auth.JWTService) AuthHandler {
 	return &authHandler{
-		adminService: adminService,
-		jwtService:   jwtService,
+		adminService:        adminService,
+		refreshTokenService: refreshTokenService,
+		totpService:         totpService,
+		jwtService:          jwtService,
 	}
 }
 
 func (h *authHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req model.AdminLoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperror.Write(w, r, errInvalidBody)
 		return
 	}
 
 	if err := validator.ValidateStruct(req); err != nil {
-		validator.HandleValidationError(w, err)
+		apperror.Write(w, r, err)
 		return
 	}
 
-	admin, err := h.adminService.Authenticate(r.Context(), &req)
+	admin, err := h.adminService.Authenticate(r.Context(), &req, middleware.ClientIP(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		apperror.Write(w, r, err)
 		return
 	}
 
-	token, err := h.jwtService.GenerateToken(admin.Username, "admin")
+	if admin.TOTPEnabled {
+		challengeToken, err := h.jwtService.GenerateChallengeToken(admin.Username)
+		if err != nil {
+			apperror.Write(w, r, fmt.Errorf("failed to generate challenge token: %w", err))
+			return
+		}
+
+		response := model.AdminLoginChallengeResponse{
+			MFARequired:    true,
+			ChallengeToken: challengeToken,
+			ExpiresAt:      time.Now().Add(auth.ChallengeTokenTTL).Unix(),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	h.writeLoginResponse(w, r, admin)
+}
+
+func (h *authHandler) LoginTOTP(w http.ResponseWriter, r *http.Request) {
+	var req model.TOTPLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	claims, err := h.jwtService.VerifyChallengeToken(req.ChallengeToken)
+	if err != nil {
+		apperror.Write(w, r, errInvalidChallenge)
+		return
+	}
+
+	admin, err := h.totpService.Verify(r.Context(), claims.Username, req.Code, middleware.ClientIP(r))
+	if errors.Is(err, service.ErrInvalidTOTPCode) || errors.Is(err, service.ErrTOTPNotEnrolled) {
+		apperror.Write(w, r, errInvalidSecondFactor)
+		return
+	}
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	h.writeLoginResponse(w, r, admin)
+}
+
+func (h *authHandler) writeLoginResponse(w http.ResponseWriter, r *http.Request, admin *model.Admin) {
+	token, err := h.jwtService.GenerateToken(admin.Username, admin.Role, admin.MustChangePassword)
 	if err != nil {
-		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		apperror.Write(w, r, fmt.Errorf("failed to generate token: %w", err))
 		return
 	}
 
-	refreshToken, err := h.jwtService.GenerateRefreshToken(admin.Username)
+	refreshToken, err := h.refreshTokenService.Issue(r.Context(), admin)
 	if err != nil {
-		http.Error(w, "Failed to generate refresh token", http.StatusInternalServerError)
+		apperror.Write(w, r, fmt.Errorf("failed to generate refresh token: %w", err))
 		return
 	}
 
-	expiresAt := time.Now().Add(15 * time.Minute).Unix()
+	expiresAt := time.Now().Add(auth.AccessTokenTTL).Unix()
 
 	response := model.AdminLoginResponse{
 		Token:        token,
 		RefreshToken: refreshToken,
 		Username:     admin.Username,
-		Role:         "admin",
+		Role:         admin.Role,
 		ExpiresAt:    expiresAt,
+
+		MustChangePassword: admin.MustChangePassword,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -76,41 +142,57 @@ func (h *authHandler) Login(w http.ResponseWriter, r *http.Request) {
 func (h *authHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	var req model.RefreshTokenRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperror.Write(w, r, errInvalidBody)
 		return
 	}
 
 	if err := validator.ValidateStruct(req); err != nil {
-		validator.HandleValidationError(w, err)
-		return
-	}
-
-	refreshClaims, err := h.jwtService.VerifyToken(req.RefreshToken)
-	if err != nil {
-		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		apperror.Write(w, r, err)
 		return
 	}
 
-	admin, err := h.adminService.FindByUsername(r.Context(), refreshClaims.Username)
+	admin, refreshToken, err := h.refreshTokenService.Rotate(r.Context(), req.RefreshToken)
 	if err != nil {
-		http.Error(w, "Admin not found", http.StatusUnauthorized)
+		apperror.Write(w, r, err)
 		return
 	}
 
-	token, err := h.jwtService.GenerateToken(admin.Username, "admin")
+	token, err := h.jwtService.GenerateToken(admin.Username, admin.Role, admin.MustChangePassword)
 	if err != nil {
-		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		apperror.Write(w, r, fmt.Errorf("failed to generate token: %w", err))
 		return
 	}
 
-	expiresAt := time.Now().Add(15 * time.Minute).Unix()
+	expiresAt := time.Now().Add(auth.AccessTokenTTL).Unix()
 
 	response := model.RefreshTokenResponse{
-		Token:     token,
-		ExpiresAt: expiresAt,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+func (h *authHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req model.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	err := h.refreshTokenService.Revoke(r.Context(), req.RefreshToken)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type CardHandler interface {
+	FindByID(w http.ResponseWriter, r *http.Request)
+	UpdateStatus(w http.ResponseWriter, r *http.Request)
+	Renew(w http.ResponseWriter, r *http.Request)
+	TransferBalance(w http.ResponseWriter, r *http.Request)
+}
+
+type cardHandler struct {
+	service service.CardService
+}
+
+func NewCardHandler(service service.CardService) CardHandler {
+	return &cardHandler{service: service}
+}
+
+func (h *cardHandler) FindByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	card, err := h.service.FindByID(r.Context(), id)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errCardNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": card,
+	})
+}
+
+func (h *cardHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	var req model.UpdateCardStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	card, err := h.service.UpdateStatus(r.Context(), actorFrom(r), id, &req)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errCardNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": card,
+	})
+}
+
+func (h *cardHandler) Renew(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	var req model.RenewCardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	renewal, err := h.service.Renew(r.Context(), actorFrom(r), id, &req)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errCardNotFound))
+		return
+	}
+
+	status := http.StatusOK
+	if renewal.ReplacedCard != nil {
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": renewal,
+	})
+}
+
+func (h *cardHandler) TransferBalance(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	var req model.TransferBalanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	transfer, err := h.service.TransferBalance(r.Context(), actorFrom(r), id, &req)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errCardNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": transfer,
+	})
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type CardHolderHandler interface {
+	FindByID(w http.ResponseWriter, r *http.Request)
+	Lookup(w http.ResponseWriter, r *http.Request)
+	FindByCard(w http.ResponseWriter, r *http.Request)
+	Register(w http.ResponseWriter, r *http.Request)
+	Update(w http.ResponseWriter, r *http.Request)
+	Unregister(w http.ResponseWriter, r *http.Request)
+}
+
+type cardHolderHandler struct {
+	service service.CardHolderService
+}
+
+func NewCardHolderHandler(service service.CardHolderService) CardHolderHandler {
+	return &cardHolderHandler{service: service}
+}
+
+func (h *cardHolderHandler) FindByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	detail, err := h.service.FindByID(r.Context(), actorFrom(r), id)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errCardHolderNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": detail,
+	})
+}
+
+// Lookup takes its criteria in the body rather than the query string so that
+// personal data does not end up in access logs.
+func (h *cardHolderHandler) Lookup(w http.ResponseWriter, r *http.Request) {
+	var req model.CardHolderLookupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	details, err := h.service.Lookup(r.Context(), actorFrom(r), &req)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": details,
+	})
+}
+
+func (h *cardHolderHandler) FindByCard(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	holder, err := h.service.FindByCard(r.Context(), actorFrom(r), id)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errCardNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": holder,
+	})
+}
+
+func (h *cardHolderHandler) Register(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	var req model.RegisterCardHolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	holder, err := h.service.Register(r.Context(), actorFrom(r), id, &req)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errCardNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": holder,
+	})
+}
+
+func (h *cardHolderHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	var req model.UpdateCardHolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	holder, err := h.service.Update(r.Context(), actorFrom(r), id, &req)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errCardHolderNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": holder,
+	})
+}
+
+func (h *cardHolderHandler) Unregister(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	if err := h.service.Unregister(r.Context(), actorFrom(r), id); err != nil {
+		apperror.Write(w, r, notFoundAs(err, errCardNotFound))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+)
+
+var (
+	errInvalidBody     = apperror.BadRequest("invalid request body")
+	errInvalidID       = apperror.BadRequest("invalid ID format")
+	errUnauthenticated = apperror.Unauthorized("authentication required")
+	errInvalidIfMatch  = apperror.PreconditionFailed("If-Match does not match any known version")
+	errInvalidDate     = apperror.BadRequest("invalid date, expected YYYY-MM-DD")
+
+	errInvalidChallenge    = apperror.Unauthorized("invalid or expired challenge token")
+	errInvalidSecondFactor = apperror.Unauthorized("invalid two-factor code")
+
+	errTerminalNotFound = apperror.NotFound("terminal not found")
+	errAdminNotFound    = apperror.NotFound("admin not found")
+	errLineNotFound     = apperror.NotFound("line not found")
+	errCardNotFound     = apperror.NotFound("card not found")
+	errGateNotFound     = apperror.NotFound("gate not found")
+
+	errOtherGate = apperror.Forbidden("gate key does not belong to this gate")
+
+	errCardHolderNotFound = apperror.NotFound("card holder not found")
+
+	errServiceExceptionNotFound = apperror.NotFound("service exception not found")
+)
+
+// notFoundAs replaces the generic repository not-found error with one naming
+// the requested entity.
+func notFoundAs(err error, notFound *apperror.Error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFound.Wrap(err)
+	}
+	return err
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type FareHandler interface {
+	PreviewGeneration(w http.ResponseWriter, r *http.Request)
+	ApplyGeneration(w http.ResponseWriter, r *http.Request)
+	Quote(w http.ResponseWriter, r *http.Request)
+	ListZones(w http.ResponseWriter, r *http.Request)
+	UpsertZone(w http.ResponseWriter, r *http.Request)
+	ListZoneFares(w http.ResponseWriter, r *http.Request)
+	UpsertZoneFare(w http.ResponseWriter, r *http.Request)
+}
+
+type fareHandler struct {
+	service service.FareService
+}
+
+func NewFareHandler(service service.FareService) FareHandler {
+	return &fareHandler{service: service}
+}
+
+func (h *fareHandler) PreviewGeneration(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeGenerateFaresRequest(r)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	result, err := h.service.PreviewGeneration(r.Context(), req)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": result,
+	})
+}
+
+func (h *fareHandler) ApplyGeneration(w http.ResponseWriter, r *http.Request) {
+	var req model.ApplyFaresRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	result, err := h.service.ApplyGeneration(r.Context(), actorFrom(r), &req)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": result,
+	})
+}
+
+func (h *fareHandler) Quote(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	originID, err := uuid.Parse(query.Get("origin"))
+	if err != nil {
+		apperror.Write(w, r, apperror.BadRequest("invalid origin, expected a terminal ID"))
+		return
+	}
+
+	destinationID, err := uuid.Parse(query.Get("destination"))
+	if err != nil {
+		apperror.Write(w, r, apperror.BadRequest("invalid destination, expected a terminal ID"))
+		return
+	}
+
+	quote, err := h.service.Quote(r.Context(), originID, destinationID)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": quote,
+	})
+}
+
+func (h *fareHandler) ListZones(w http.ResponseWriter, r *http.Request) {
+	zones, err := h.service.ListZones(r.Context())
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": zones,
+	})
+}
+
+func (h *fareHandler) UpsertZone(w http.ResponseWriter, r *http.Request) {
+	zone, err := strconv.Atoi(chi.URLParam(r, "zone"))
+	if err != nil || zone < 1 {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	var req model.UpsertFareZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	fareZone, err := h.service.UpsertZone(r.Context(), actorFrom(r), zone, &req)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": fareZone,
+	})
+}
+
+func (h *fareHandler) ListZoneFares(w http.ResponseWriter, r *http.Request) {
+	fares, err := h.service.ListZoneFares(r.Context())
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": fares,
+	})
+}
+
+func (h *fareHandler) UpsertZoneFare(w http.ResponseWriter, r *http.Request) {
+	zones, err := strconv.Atoi(chi.URLParam(r, "zones"))
+	if err != nil || zones < 1 {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	var req model.UpsertZoneFareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	fare, err := h.service.UpsertZoneFare(r.Context(), actorFrom(r), zones, &req)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": fare,
+	})
+}
+
+// decodeGenerateFaresRequest accepts an empty body, in which case the
+// configured defaults are used for every parameter.
+func decodeGenerateFaresRequest(r *http.Request) (*model.GenerateFaresRequest, error) {
+	var req model.GenerateFaresRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errInvalidBody
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
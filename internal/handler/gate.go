@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/middleware"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type GateHandler interface {
+	IssueKey(w http.ResponseWriter, r *http.Request)
+	ReportTapFailure(w http.ResponseWriter, r *http.Request)
+}
+
+type gateHandler struct {
+	service service.GateService
+}
+
+func NewGateHandler(service service.GateService) GateHandler {
+	return &gateHandler{service: service}
+}
+
+func (h *gateHandler) IssueKey(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	key, err := h.service.IssueKey(r.Context(), actorFrom(r), id)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errGateNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": key,
+	})
+}
+
+// ReportTapFailure stores a tap the gate rejected. A gate authenticated with
+// its key may only report for itself.
+func (h *gateHandler) ReportTapFailure(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	if gateID, ok := middleware.GateIDFromContext(r.Context()); ok && gateID != id {
+		apperror.Write(w, r, errOtherGate)
+		return
+	}
+
+	var req model.ReportTapFailureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	failure, err := h.service.ReportTapFailure(r.Context(), id, &req)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errGateNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": failure,
+	})
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/validator"
+)
+
+type HotlistHandler interface {
+	Changes(w http.ResponseWriter, r *http.Request)
+	Bloom(w http.ResponseWriter, r *http.Request)
+}
+
+type hotlistHandler struct {
+	service service.HotlistService
+}
+
+func NewHotlistHandler(service service.HotlistService) HotlistHandler {
+	return &hotlistHandler{service: service}
+}
+
+func (h *hotlistHandler) Changes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := model.HotlistFilter{Limit: 500}
+
+	if value := query.Get("since"); value != "" {
+		since, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			apperror.Write(w, r, apperror.BadRequest("invalid since, expected an integer"))
+			return
+		}
+		filter.Since = since
+	}
+
+	if err := queryInt(query, "limit", &filter.Limit); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	if err := validator.ValidateStruct(filter); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	delta, err := h.service.Changes(r.Context(), filter.Since, filter.Limit)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": delta,
+	})
+}
+
+// Bloom serves the binary Bloom filter of blocked cards. The hotlist version
+// doubles as the ETag, so gates can poll with If-None-Match cheaply: the
+// version is checked before the filter is built.
+func (h *hotlistHandler) Bloom(w http.ResponseWriter, r *http.Request) {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		version, err := h.service.Version(r.Context())
+		if err != nil {
+			apperror.Write(w, r, err)
+			return
+		}
+
+		if match == hotlistETag(version) {
+			setHotlistVersion(w, version)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	version, data, err := h.service.Bloom(r.Context())
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	setHotlistVersion(w, version)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
+
+func hotlistETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+func setHotlistVersion(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", hotlistETag(version))
+	w.Header().Set("X-Hotlist-Version", strconv.FormatInt(version, 10))
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type LineHandler interface {
+	List(w http.ResponseWriter, r *http.Request)
+	FindByID(w http.ResponseWriter, r *http.Request)
+	Create(w http.ResponseWriter, r *http.Request)
+	Update(w http.ResponseWriter, r *http.Request)
+	ReplaceStops(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+}
+
+type lineHandler struct {
+	service service.LineService
+}
+
+func NewLineHandler(service service.LineService) LineHandler {
+	return &lineHandler{service: service}
+}
+
+func (h *lineHandler) List(w http.ResponseWriter, r *http.Request) {
+	lines, err := h.service.List(r.Context())
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": lines,
+	})
+}
+
+func (h *lineHandler) FindByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	line, err := h.service.FindByID(r.Context(), id)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errLineNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": line,
+	})
+}
+
+func (h *lineHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateLineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	line, err := h.service.Create(r.Context(), actorFrom(r), &req)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": line,
+	})
+}
+
+func (h *lineHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	var req model.UpdateLineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	line, err := h.service.Update(r.Context(), actorFrom(r), id, &req)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errLineNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": line,
+	})
+}
+
+func (h *lineHandler) ReplaceStops(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	var req model.ReplaceLineStopsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	line, err := h.service.ReplaceStops(r.Context(), actorFrom(r), id, &req)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errLineNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": line,
+	})
+}
+
+func (h *lineHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	err = h.service.Delete(r.Context(), actorFrom(r), id)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errLineNotFound))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
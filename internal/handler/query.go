@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+)
+
+// queryInt parses the integer query parameter key into dst, leaving dst
+// untouched when the parameter is absent.
+func queryInt(query url.Values, key string, dst *int) error {
+	value := query.Get(key)
+	if value == "" {
+		return nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return apperror.BadRequest("invalid " + key + ", expected an integer")
+	}
+
+	*dst = n
+	return nil
+}
+
+// queryBool parses the boolean query parameter key, returning nil when the
+// parameter is absent.
+func queryBool(query url.Values, key string) (*bool, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, apperror.BadRequest("invalid " + key + ", expected a boolean")
+	}
+
+	return &b, nil
+}
+
+// queryFloat parses the decimal query parameter key, returning nil when the
+// parameter is absent.
+func queryFloat(query url.Values, key string) (*float64, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, apperror.BadRequest("invalid " + key + ", expected a number")
+	}
+
+	return &f, nil
+}
@@ -2,9 +2,12 @@ package handler
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/validator"
 	"github.com/go-chi/chi/v5"
@@ -14,9 +17,12 @@ import (
 type TerminalHandler interface {
 	List(w http.ResponseWriter, r *http.Request)
 	FindByID(w http.ResponseWriter, r *http.Request)
+	Nearby(w http.ResponseWriter, r *http.Request)
 	Create(w http.ResponseWriter, r *http.Request)
 	Update(w http.ResponseWriter, r *http.Request)
+	Patch(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
+	Restore(w http.ResponseWriter, r *http.Request)
 }
 
 type terminalHandler struct {
@@ -28,55 +34,151 @@ func NewTerminalHandler(service service.TerminalService) TerminalHandler {
 }
 
 func (h *terminalHandler) List(w http.ResponseWriter, r *http.Request) {
-	terminals, err := h.service.List(r.Context())
+	query := r.URL.Query()
+
+	filter := model.TerminalFilter{
+		Search: query.Get("search"),
+		Sort:   query.Get("sort"),
+		Order:  query.Get("order"),
+		Limit:  50,
+	}
+
+	var err error
+	if filter.IsActive, err = queryBool(query, "is_active"); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	if filter.IncludeDeleted, err = includeDeletedParam(r); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	if err := queryInt(query, "limit", &filter.Limit); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+	if err := queryInt(query, "offset", &filter.Offset); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	if err := validator.ValidateStruct(filter); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	terminals, total, err := h.service.List(r.Context(), &filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{
 		"data": terminals,
+		"meta": model.PageMeta{
+			Total:  total,
+			Limit:  filter.Limit,
+			Offset: filter.Offset,
+		},
 	})
 }
 
 func (h *terminalHandler) FindByID(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	includeDeleted, err := includeDeletedParam(r)
+	if err != nil {
+		apperror.Write(w, r, err)
 		return
 	}
 
-	terminal, err := h.service.FindByID(r.Context(), id)
+	terminal, err := h.service.FindByID(r.Context(), id, includeDeleted)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apperror.Write(w, r, notFoundAs(err, errTerminalNotFound))
 		return
 	}
 
+	setETag(w, terminal.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{
 		"data": terminal,
 	})
 }
 
+func (h *terminalHandler) Nearby(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := model.NearbyTerminalFilter{
+		RadiusKm: 5,
+		Limit:    20,
+	}
+
+	var err error
+	if filter.Latitude, err = queryFloat(query, "lat"); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+	if filter.Longitude, err = queryFloat(query, "lng"); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	radius, err := queryFloat(query, "radius")
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+	if radius != nil {
+		filter.RadiusKm = *radius
+	}
+
+	if err := queryInt(query, "limit", &filter.Limit); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	if err := validator.ValidateStruct(filter); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	terminals, err := h.service.Nearby(r.Context(), &filter)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": terminals,
+	})
+}
+
 func (h *terminalHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req model.CreateTerminalRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperror.Write(w, r, errInvalidBody)
 		return
 	}
 
 	if err := validator.ValidateStruct(req); err != nil {
-		validator.HandleValidationError(w, err)
+		apperror.Write(w, r, err)
 		return
 	}
 
-	terminal, err := h.service.Create(r.Context(), &req)
+	terminal, err := h.service.Create(r.Context(), actorFrom(r), &req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, r, err)
 		return
 	}
 
+	setETag(w, terminal.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]any{
@@ -87,22 +189,110 @@ func (h *terminalHandler) Create(w http.ResponseWriter, r *http.Request) {
 func (h *terminalHandler) Update(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		apperror.Write(w, r, err)
 		return
 	}
 
 	var req model.UpdateTerminalRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	terminal, err := h.service.Update(r.Context(), actorFrom(r), id, &req, version)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errTerminalNotFound))
 		return
 	}
 
-	terminal, err := h.service.Update(r.Context(), id, &req)
+	setETag(w, terminal.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": terminal,
+	})
+}
+
+// Patch applies a JSON Merge Patch to the terminal. The patch is merged
+// against the version that was read, so a concurrent write in between is
+// rejected instead of overwritten.
+func (h *terminalHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	current, err := h.service.FindByID(r.Context(), id, false)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errTerminalNotFound))
+		return
+	}
+
+	if version != nil && *version != current.Version {
+		apperror.Write(w, r, repository.ErrStale)
+		return
+	}
+
+	doc, err := json.Marshal(model.UpdateTerminalRequest{
+		Name:      current.Name,
+		Address:   current.Address,
+		Latitude:  current.Latitude,
+		Longitude: current.Longitude,
+		FareZone:  current.FareZone,
+		IsActive:  &current.IsActive,
+	})
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	merged, err := mergePatch(doc, patch)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, r, errInvalidBody)
 		return
 	}
 
+	var req model.UpdateTerminalRequest
+	if err := json.Unmarshal(merged, &req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	terminal, err := h.service.Update(r.Context(), actorFrom(r), id, &req, &current.Version)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errTerminalNotFound))
+		return
+	}
+
+	setETag(w, terminal.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{
 		"data": terminal,
@@ -112,15 +302,44 @@ func (h *terminalHandler) Update(w http.ResponseWriter, r *http.Request) {
 func (h *terminalHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		apperror.Write(w, r, errInvalidID)
 		return
 	}
 
-	err = h.service.Delete(r.Context(), id)
+	err = h.service.Delete(r.Context(), actorFrom(r), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperror.Write(w, r, notFoundAs(err, errTerminalNotFound))
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *terminalHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	terminal, err := h.service.Restore(r.Context(), actorFrom(r), id)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errTerminalNotFound))
+		return
+	}
+
+	setETag(w, terminal.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": terminal,
+	})
+}
+
+func includeDeletedParam(r *http.Request) (bool, error) {
+	includeDeleted, err := queryBool(r.URL.Query(), "include_deleted")
+	if err != nil || includeDeleted == nil {
+		return false, err
+	}
+
+	return *includeDeleted, nil
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type TerminalScheduleHandler interface {
+	Get(w http.ResponseWriter, r *http.Request)
+	ReplaceHours(w http.ResponseWriter, r *http.Request)
+	UpsertException(w http.ResponseWriter, r *http.Request)
+	DeleteException(w http.ResponseWriter, r *http.Request)
+	Status(w http.ResponseWriter, r *http.Request)
+	CheckTapIn(w http.ResponseWriter, r *http.Request)
+}
+
+type terminalScheduleHandler struct {
+	service service.TerminalScheduleService
+}
+
+func NewTerminalScheduleHandler(service service.TerminalScheduleService) TerminalScheduleHandler {
+	return &terminalScheduleHandler{service: service}
+}
+
+func (h *terminalScheduleHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	schedule, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errTerminalNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": schedule,
+	})
+}
+
+func (h *terminalScheduleHandler) ReplaceHours(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	var req model.ReplaceServiceHoursRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	schedule, err := h.service.ReplaceHours(r.Context(), actorFrom(r), id, &req)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errTerminalNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": schedule,
+	})
+}
+
+func (h *terminalScheduleHandler) UpsertException(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	date, err := time.Parse(time.DateOnly, chi.URLParam(r, "date"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidDate)
+		return
+	}
+
+	var req model.UpsertServiceExceptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	exception, err := h.service.UpsertException(r.Context(), actorFrom(r), id, date, &req)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errTerminalNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": exception,
+	})
+}
+
+func (h *terminalScheduleHandler) DeleteException(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	date, err := time.Parse(time.DateOnly, chi.URLParam(r, "date"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidDate)
+		return
+	}
+
+	if err := h.service.DeleteException(r.Context(), actorFrom(r), id, date); err != nil {
+		apperror.Write(w, r, notFoundAs(err, errServiceExceptionNotFound))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *terminalScheduleHandler) Status(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	at := time.Now()
+	if value := r.URL.Query().Get("at"); value != "" {
+		at, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			apperror.Write(w, r, apperror.BadRequest("invalid at, expected RFC3339"))
+			return
+		}
+	}
+
+	status, err := h.service.Status(r.Context(), id, at)
+	if err != nil {
+		apperror.Write(w, r, notFoundAs(err, errTerminalNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": status,
+	})
+}
+
+// CheckTapIn answers 204 when a tap-in at the terminal is allowed now and 403
+// with the closing reason otherwise. Gates call it before accepting a tap-in.
+func (h *terminalScheduleHandler) CheckTapIn(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apperror.Write(w, r, errInvalidID)
+		return
+	}
+
+	if err := h.service.CheckTapIn(r.Context(), id, time.Now()); err != nil {
+		apperror.Write(w, r, notFoundAs(err, errTerminalNotFound))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/middleware"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/validator"
+)
+
+type TOTPHandler interface {
+	Enroll(w http.ResponseWriter, r *http.Request)
+	Confirm(w http.ResponseWriter, r *http.Request)
+	Disable(w http.ResponseWriter, r *http.Request)
+}
+
+type totpHandler struct {
+	service service.TOTPService
+}
+
+func NewTOTPHandler(service service.TOTPService) TOTPHandler {
+	return &totpHandler{service: service}
+}
+
+func (h *totpHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	username, ok := middleware.UsernameFromContext(r.Context())
+	if !ok {
+		apperror.Write(w, r, errUnauthenticated)
+		return
+	}
+
+	enrollment, err := h.service.Enroll(r.Context(), username)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": enrollment,
+	})
+}
+
+func (h *totpHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	if _, ok := middleware.UsernameFromContext(r.Context()); !ok {
+		apperror.Write(w, r, errUnauthenticated)
+		return
+	}
+
+	var req model.TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	codes, err := h.service.Confirm(r.Context(), actorFrom(r), req.Code)
+	if err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": model.RecoveryCodesResponse{RecoveryCodes: codes},
+	})
+}
+
+func (h *totpHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	if _, ok := middleware.UsernameFromContext(r.Context()); !ok {
+		apperror.Write(w, r, errUnauthenticated)
+		return
+	}
+
+	var req model.TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.Write(w, r, errInvalidBody)
+		return
+	}
+
+	if err := validator.ValidateStruct(req); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	if err := h.service.Disable(r.Context(), actorFrom(r), req.Code); err != nil {
+		apperror.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
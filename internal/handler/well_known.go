@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/auth"
+)
+
+type WellKnownHandler interface {
+	JWKS(w http.ResponseWriter, r *http.Request)
+}
+
+type wellKnownHandler struct {
+	jwtService auth.JWTService
+}
+
+func NewWellKnownHandler(jwtService auth.JWTService) WellKnownHandler {
+	return &wellKnownHandler{jwtService: jwtService}
+}
+
+func (h *wellKnownHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.jwtService.JWKS())
+}
@@ -0,0 +1,33 @@
+// Package job runs periodic background work alongside the HTTP server.
+package job
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs fn once immediately and then at each interval until ctx is
+// cancelled. A non-positive interval disables the job. Errors are logged
+// and the job keeps running on its schedule.
+func Every(ctx context.Context, name string, interval time.Duration, fn func(context.Context) error) {
+	if interval <= 0 {
+		log.Printf("job %s disabled", name)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(ctx); err != nil {
+			log.Printf("job %s failed: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,17 +2,36 @@ package middleware
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"strings"
 
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/auth"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/google/uuid"
 )
 
+// GateKeyHeader carries the API key issued to a gate.
+const GateKeyHeader = "X-Gate-Key"
+
 type contextKey string
 
 const (
 	usernameKey contextKey = "username"
 	roleKey     contextKey = "role"
+	clientIPKey contextKey = "client_ip"
+	gateIDKey   contextKey = "gate_id"
+
+	mustChangePasswordKey contextKey = "must_change_password"
+)
+
+var (
+	errMissingAuthorization    = apperror.Unauthorized("authorization header required")
+	errInvalidAuthorization    = apperror.Unauthorized("invalid authorization header format")
+	errInvalidToken            = apperror.Unauthorized("invalid or expired token")
+	errInsufficientPermissions = apperror.Forbidden("insufficient permissions")
+	errPasswordChangeRequired  = apperror.Forbidden("password change required")
 )
 
 func AdminAuthMiddleware(jwtService auth.JWTService) func(http.Handler) http.Handler {
@@ -20,12 +39,12 @@ func AdminAuthMiddleware(jwtService auth.JWTService) func(http.Handler) http.Han
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "Authorization header required", http.StatusUnauthorized)
+				apperror.Write(w, r, errMissingAuthorization)
 				return
 			}
 
 			if !strings.HasPrefix(authHeader, "Bearer ") {
-				http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+				apperror.Write(w, r, errInvalidAuthorization)
 				return
 			}
 
@@ -33,14 +52,109 @@ func AdminAuthMiddleware(jwtService auth.JWTService) func(http.Handler) http.Han
 
 			claims, err := jwtService.ValidateToken(tokenString)
 			if err != nil {
-				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				apperror.Write(w, r, errInvalidToken)
 				return
 			}
 
 			ctx := context.WithValue(r.Context(), usernameKey, claims.Username)
 			ctx = context.WithValue(ctx, roleKey, claims.Role)
+			ctx = context.WithValue(ctx, clientIPKey, ClientIP(r))
+			ctx = context.WithValue(ctx, mustChangePasswordKey, claims.MustChangePassword)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GateAuthenticator resolves the active gate owning an API key.
+type GateAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*model.Gate, error)
+}
+
+// GateOrAdminAuth admits gates presenting their key in GateKeyHeader. Requests
+// without it are authenticated as admins and must hold permission.
+func GateOrAdminAuth(gates GateAuthenticator, jwtService auth.JWTService, permission string) func(http.Handler) http.Handler {
+	adminAuth := AdminAuthMiddleware(jwtService)
+
+	return func(next http.Handler) http.Handler {
+		asAdmin := adminAuth(RequirePasswordChanged()(RequirePermission(permission)(next)))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(GateKeyHeader)
+			if key == "" {
+				asAdmin.ServeHTTP(w, r)
+				return
+			}
+
+			gate, err := gates.Authenticate(r.Context(), key)
+			if err != nil {
+				apperror.Write(w, r, err)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), gateIDKey, gate.ID)
+			ctx = context.WithValue(ctx, clientIPKey, ClientIP(r))
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := RoleFromContext(r.Context())
+			if !auth.HasPermission(role, permission) {
+				apperror.Write(w, r, errInsufficientPermissions.WithDetails(map[string]string{"permission": permission}))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePasswordChanged rejects requests from admins whose password must be
+// changed. Only the password change route should be mounted outside it.
+func RequirePasswordChanged() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if mustChange, _ := r.Context().Value(mustChangePasswordKey).(bool); mustChange {
+				apperror.Write(w, r, errPasswordChangeRequired)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func UsernameFromContext(ctx context.Context) (string, bool) {
+	username, ok := ctx.Value(usernameKey).(string)
+	return username, ok
+}
+
+func RoleFromContext(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(roleKey).(string)
+	return role, ok
+}
+
+func GateIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(gateIDKey).(uuid.UUID)
+	return id, ok
+}
+
+func ClientIPFromContext(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(clientIPKey).(string)
+	return ip, ok
+}
+
+// ClientIP returns the caller address as rewritten by chi's RealIP middleware,
+// without the port that net/http leaves on RemoteAddr.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
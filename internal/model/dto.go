@@ -1,25 +1,195 @@
 package model
 
+import (
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/money"
+	"github.com/google/uuid"
+)
+
 type CreateTerminalRequest struct {
-	Code    string `json:"code" validate:"required,max=10"`
-	Name    string `json:"name" validate:"required,max=100"`
-	Address string `json:"address" validate:"required"`
+	Code      string   `json:"code" validate:"required,max=10"`
+	Name      string   `json:"name" validate:"required,max=100"`
+	Address   string   `json:"address" validate:"required"`
+	Latitude  *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,latitude"`
+	Longitude *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,longitude"`
+	FareZone  *int     `json:"fare_zone" validate:"omitempty,min=1"`
 }
 
 type UpdateTerminalRequest struct {
-	Name     string `json:"name" validate:"required,max=100"`
-	Address  string `json:"address" validate:"required"`
-	IsActive bool   `json:"is_active"`
+	Name      string   `json:"name" validate:"required,max=100"`
+	Address   string   `json:"address" validate:"required"`
+	Latitude  *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,latitude"`
+	Longitude *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,longitude"`
+	FareZone  *int     `json:"fare_zone" validate:"omitempty,min=1"`
+	IsActive  *bool    `json:"is_active" validate:"required"`
+}
+
+type NearbyTerminalFilter struct {
+	Latitude  *float64 `json:"lat" validate:"required,latitude"`
+	Longitude *float64 `json:"lng" validate:"required,longitude"`
+	RadiusKm  float64  `json:"radius" validate:"gt=0,max=100"`
+	Limit     int      `json:"limit" validate:"min=1,max=100"`
+}
+
+type TerminalFilter struct {
+	IsActive       *bool  `json:"is_active"`
+	Search         string `json:"search" validate:"omitempty,max=100"`
+	IncludeDeleted bool   `json:"include_deleted"`
+	Sort           string `json:"sort" validate:"omitempty,oneof=code name created_at updated_at"`
+	Order          string `json:"order" validate:"omitempty,oneof=asc desc"`
+	Limit          int    `json:"limit" validate:"min=1,max=200"`
+	Offset         int    `json:"offset" validate:"min=0"`
+}
+
+type PageMeta struct {
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+type ServiceHoursRequest struct {
+	DayOfWeek *int   `json:"day_of_week" validate:"required,min=0,max=6"`
+	OpensAt   string `json:"opens_at" validate:"required,datetime=15:04"`
+	ClosesAt  string `json:"closes_at" validate:"required,datetime=15:04"`
+}
+
+type ReplaceServiceHoursRequest struct {
+	Hours []ServiceHoursRequest `json:"hours" validate:"max=7,dive"`
+}
+
+type UpsertServiceExceptionRequest struct {
+	OpensAt  *string `json:"opens_at" validate:"required_with=ClosesAt,omitempty,datetime=15:04"`
+	ClosesAt *string `json:"closes_at" validate:"required_with=OpensAt,omitempty,datetime=15:04"`
+	Note     *string `json:"note" validate:"omitempty,max=200"`
+}
+
+type TerminalServiceSchedule struct {
+	Hours      []ServiceHours     `json:"hours"`
+	Exceptions []ServiceException `json:"exceptions"`
+}
+
+type TerminalOpenStatus struct {
+	TerminalID   uuid.UUID  `json:"terminal_id"`
+	At           time.Time  `json:"at"`
+	Open         bool       `json:"open"`
+	TapInAllowed bool       `json:"tap_in_allowed"`
+	Reason       string     `json:"reason,omitempty"`
+	OpensAt      *time.Time `json:"opens_at,omitempty"`
+	ClosesAt     *time.Time `json:"closes_at,omitempty"`
+}
+
+type CreateLineRequest struct {
+	Code        string      `json:"code" validate:"required,max=10"`
+	Name        string      `json:"name" validate:"required,max=100"`
+	Color       *string     `json:"color" validate:"omitempty,hexcolor,max=7"`
+	TerminalIDs []uuid.UUID `json:"terminal_ids" validate:"omitempty,min=2"`
+}
+
+type UpdateLineRequest struct {
+	Name     string  `json:"name" validate:"required,max=100"`
+	Color    *string `json:"color" validate:"omitempty,hexcolor,max=7"`
+	IsActive *bool   `json:"is_active" validate:"required"`
+}
+
+type ReplaceLineStopsRequest struct {
+	TerminalIDs []uuid.UUID `json:"terminal_ids" validate:"required,min=2"`
+}
+
+type UpdateCardStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=active blocked"`
+}
+
+type RenewCardRequest struct {
+	ReplaceCard bool    `json:"replace_card"`
+	CardNumber  *string `json:"card_number" validate:"omitempty,numeric,len=16"`
+	Reason      *string `json:"reason" validate:"omitempty,max=255"`
+}
+
+type CardRenewal struct {
+	Card         *Card `json:"card"`
+	ReplacedCard *Card `json:"replaced_card,omitempty"`
+}
+
+type TransferBalanceRequest struct {
+	TargetCardID uuid.UUID `json:"target_card_id" validate:"required"`
+}
+
+type BalanceTransfer struct {
+	Amount  money.Amount  `json:"amount"`
+	Source  *Card         `json:"source"`
+	Target  *Card         `json:"target"`
+	Entries []LedgerEntry `json:"entries"`
+}
+
+type RegisterCardHolderRequest struct {
+	Name     string  `json:"name" validate:"required,max=100"`
+	Phone    *string `json:"phone" validate:"omitempty,e164"`
+	Email    *string `json:"email" validate:"omitempty,email,max=255"`
+	IDNumber string  `json:"id_number" validate:"required,alphanum,min=6,max=32"`
+}
+
+type UpdateCardHolderRequest struct {
+	Name  string  `json:"name" validate:"required,max=100"`
+	Phone *string `json:"phone" validate:"omitempty,e164"`
+	Email *string `json:"email" validate:"omitempty,email,max=255"`
+}
+
+type CardHolderLookupRequest struct {
+	Phone    *string `json:"phone" validate:"omitempty,e164"`
+	Email    *string `json:"email" validate:"omitempty,email,max=255"`
+	IDNumber *string `json:"id_number" validate:"omitempty,alphanum,min=6,max=32"`
+}
+
+type CardHolderDetail struct {
+	Holder *CardHolder `json:"holder"`
+	Cards  []Card      `json:"cards"`
+}
+
+type HotlistFilter struct {
+	Since int64 `json:"since" validate:"min=0"`
+	Limit int   `json:"limit" validate:"min=1,max=5000"`
+}
+
+type HotlistDelta struct {
+	Since   int64          `json:"since"`
+	Version int64          `json:"version"`
+	HasMore bool           `json:"has_more"`
+	Changes []HotlistEntry `json:"changes"`
+}
+
+// GateKey is returned once when a key is issued; only its hash is stored.
+type GateKey struct {
+	GateID   uuid.UUID `json:"gate_id"`
+	Key      string    `json:"key"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// ReportTapFailureRequest is sent by a gate for every tap it rejects.
+// CardNumber is empty when the card could not be read, and OccurredAt
+// defaults to the time the report is received.
+type ReportTapFailureRequest struct {
+	CardNumber *string    `json:"card_number" validate:"omitempty,numeric,max=16"`
+	TapType    string     `json:"tap_type" validate:"required,oneof=tap_in tap_out"`
+	Reason     string     `json:"reason" validate:"required,max=50"`
+	OccurredAt *time.Time `json:"occurred_at"`
 }
 
 type CreateAdminRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=50"`
-	Password string `json:"password" validate:"required,min=6"`
+	Password string `json:"password" validate:"required,max=72"`
+	Role     string `json:"role" validate:"omitempty,oneof=superadmin operator finance support viewer"`
 }
 
 type UpdateAdminRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=50"`
-	Password string `json:"password" validate:"omitempty,min=6"`
+	Password string `json:"password" validate:"omitempty,max=72"`
+	Role     string `json:"role" validate:"omitempty,oneof=superadmin operator finance support viewer"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,max=72"`
 }
 
 type AdminLoginRequest struct {
@@ -33,6 +203,32 @@ type AdminLoginResponse struct {
 	Username     string `json:"username"`
 	Role         string `json:"role"`
 	ExpiresAt    int64  `json:"expires_at"`
+
+	MustChangePassword bool `json:"must_change_password"`
+}
+
+type AdminLoginChallengeResponse struct {
+	MFARequired    bool   `json:"mfa_required"`
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresAt      int64  `json:"expires_at"`
+}
+
+type TOTPLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
 
 type RefreshTokenRequest struct {
@@ -40,6 +236,95 @@ type RefreshTokenRequest struct {
 }
 
 type RefreshTokenResponse struct {
-	Token     string `json:"token"`
-	ExpiresAt int64  `json:"expires_at"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresAt    int64  `json:"expires_at"`
+}
+
+type AuditLogFilter struct {
+	Actor      string     `json:"actor" validate:"omitempty,max=50"`
+	Action     string     `json:"action" validate:"omitempty,max=50"`
+	EntityType string     `json:"entity_type" validate:"omitempty,max=50"`
+	EntityID   string     `json:"entity_id" validate:"omitempty,max=64"`
+	From       *time.Time `json:"from" validate:"omitempty"`
+	To         *time.Time `json:"to" validate:"omitempty"`
+	Limit      int        `json:"limit" validate:"min=1,max=200"`
+	Offset     int        `json:"offset" validate:"min=0"`
+}
+
+type AlertFilter struct {
+	AlertType string     `json:"alert_type" validate:"omitempty,oneof=low_balance repeated_failed_taps impossible_travel"`
+	CardID    *uuid.UUID `json:"card_id"`
+	From      *time.Time `json:"from"`
+	To        *time.Time `json:"to"`
+	Limit     int        `json:"limit" validate:"min=1,max=200"`
+	Offset    int        `json:"offset" validate:"min=0"`
+}
+
+type GenerateFaresRequest struct {
+	BaseFare  *money.Amount `json:"base_fare" validate:"omitempty,min=0"`
+	PerKmRate *money.Amount `json:"per_km_rate" validate:"omitempty,min=0"`
+	Rounding  *money.Amount `json:"rounding" validate:"omitempty,min=0"`
+}
+
+// ApplyFaresRequest applies a previewed generation. Version is the one
+// returned by the preview with the same parameters.
+type ApplyFaresRequest struct {
+	GenerateFaresRequest
+	Version string `json:"version" validate:"required"`
+}
+
+type FareChange struct {
+	OriginTerminalID      uuid.UUID     `json:"origin_terminal_id"`
+	OriginCode            string        `json:"origin_code"`
+	DestinationTerminalID uuid.UUID     `json:"destination_terminal_id"`
+	DestinationCode       string        `json:"destination_code"`
+	DistanceKm            float64       `json:"distance_km"`
+	DistanceSource        string        `json:"distance_source"`
+	CurrentAmount         *money.Amount `json:"current_amount"`
+	NewAmount             money.Amount  `json:"new_amount"`
+	Status                string        `json:"status"`
+}
+
+type SkippedFare struct {
+	OriginCode      string `json:"origin_code"`
+	DestinationCode string `json:"destination_code"`
+	Reason          string `json:"reason"`
+}
+
+type FareGenerationSummary struct {
+	Added     int `json:"added"`
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"`
+}
+
+type FareGenerationResult struct {
+	Currency  string                `json:"currency"`
+	BaseFare  money.Amount          `json:"base_fare"`
+	PerKmRate money.Amount          `json:"per_km_rate"`
+	Rounding  money.Amount          `json:"rounding"`
+	Version   string                `json:"version"`
+	Applied   bool                  `json:"applied"`
+	Summary   FareGenerationSummary `json:"summary"`
+	Changes   []FareChange          `json:"changes"`
+	Skipped   []SkippedFare         `json:"skipped"`
+}
+
+type UpsertFareZoneRequest struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+type UpsertZoneFareRequest struct {
+	FareAmount *money.Amount `json:"fare_amount" validate:"required,min=0"`
+}
+
+type FareQuote struct {
+	OriginTerminalID      uuid.UUID    `json:"origin_terminal_id"`
+	DestinationTerminalID uuid.UUID    `json:"destination_terminal_id"`
+	FareAmount            money.Amount `json:"fare_amount"`
+	Currency              string       `json:"currency"`
+	FareDisplay           string       `json:"fare_display"`
+	Model                 string       `json:"model"`
+	ZonesTravelled        *int         `json:"zones_travelled,omitempty"`
 }
@@ -1,19 +1,71 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/aliffatulmf/mkp-eticket-service/internal/money"
 	"github.com/google/uuid"
 )
 
 type Terminal struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	Code      string    `json:"code" db:"code"`
-	Name      string    `json:"name" db:"name"`
-	Address   string    `json:"address" db:"address"`
-	IsActive  bool      `json:"is_active" db:"is_active"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID        uuid.UUID     `json:"id" db:"id"`
+	Code      string        `json:"code" db:"code"`
+	Name      string        `json:"name" db:"name"`
+	Address   string        `json:"address" db:"address"`
+	Latitude  *float64      `json:"latitude" db:"latitude"`
+	Longitude *float64      `json:"longitude" db:"longitude"`
+	FareZone  *int          `json:"fare_zone" db:"fare_zone"`
+	IsActive  bool          `json:"is_active" db:"is_active"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at" db:"updated_at"`
+	Version   int           `json:"version" db:"version"`
+	DeletedAt *time.Time    `json:"deleted_at,omitempty" db:"deleted_at"`
+	Lines     []LineSummary `json:"lines,omitempty" db:"-"`
+}
+
+type NearbyTerminal struct {
+	Terminal
+	DistanceKm float64 `json:"distance_km" db:"distance_km"`
+}
+
+type ServiceHours struct {
+	DayOfWeek int    `json:"day_of_week" db:"day_of_week"`
+	OpensAt   string `json:"opens_at" db:"opens_at"`
+	ClosesAt  string `json:"closes_at" db:"closes_at"`
+}
+
+type ServiceException struct {
+	Date     string  `json:"date" db:"service_date"`
+	OpensAt  *string `json:"opens_at" db:"opens_at"`
+	ClosesAt *string `json:"closes_at" db:"closes_at"`
+	Note     *string `json:"note" db:"note"`
+}
+
+type Line struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Code      string     `json:"code" db:"code"`
+	Name      string     `json:"name" db:"name"`
+	Color     *string    `json:"color" db:"color"`
+	IsActive  bool       `json:"is_active" db:"is_active"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	Stops     []LineStop `json:"stops,omitempty" db:"-"`
+}
+
+type LineStop struct {
+	Sequence     int       `json:"sequence" db:"sequence"`
+	TerminalID   uuid.UUID `json:"terminal_id" db:"terminal_id"`
+	TerminalCode string    `json:"terminal_code" db:"terminal_code"`
+	TerminalName string    `json:"terminal_name" db:"terminal_name"`
+}
+
+type LineSummary struct {
+	ID       uuid.UUID `json:"id" db:"id"`
+	Code     string    `json:"code" db:"code"`
+	Name     string    `json:"name" db:"name"`
+	Color    *string   `json:"color" db:"color"`
+	Sequence int       `json:"sequence" db:"sequence"`
 }
 
 type Gate struct {
@@ -28,38 +80,174 @@ type Gate struct {
 }
 
 type Card struct {
-	ID         uuid.UUID `json:"id" db:"id"`
+	ID         uuid.UUID    `json:"id" db:"id"`
+	CardNumber string       `json:"card_number" db:"card_number"`
+	Balance    money.Amount `json:"balance" db:"balance"`
+	Status     string       `json:"status" db:"status"`
+	IssuedDate time.Time    `json:"issued_date" db:"issued_date"`
+	ExpiryDate time.Time    `json:"expiry_date" db:"expiry_date"`
+	ReplacedBy *uuid.UUID   `json:"replaced_by" db:"replaced_by"`
+	HolderID   *uuid.UUID   `json:"holder_id" db:"holder_id"`
+	CreatedAt  time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// CardHolder is the registered owner of a personalised card. The identity
+// number is never stored or returned in clear text.
+type CardHolder struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	Name         string    `json:"name" db:"name"`
+	Phone        *string   `json:"phone" db:"phone"`
+	Email        *string   `json:"email" db:"email"`
+	IDNumberHash string    `json:"-" db:"id_number_hash"`
+	Masked       bool      `json:"masked" db:"-"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type LedgerEntry struct {
+	ID            int64        `json:"id" db:"id"`
+	CardID        uuid.UUID    `json:"card_id" db:"card_id"`
+	EntryType     string       `json:"entry_type" db:"entry_type"`
+	Amount        money.Amount `json:"amount" db:"amount"`
+	BalanceAfter  money.Amount `json:"balance_after" db:"balance_after"`
+	Reason        string       `json:"reason" db:"reason"`
+	CounterpartID *int64       `json:"counterpart_id" db:"counterpart_id"`
+	CreatedAt     time.Time    `json:"created_at" db:"created_at"`
+}
+
+type HotlistEntry struct {
+	Version    int64     `json:"version" db:"version"`
+	CardID     uuid.UUID `json:"card_id" db:"card_id"`
 	CardNumber string    `json:"card_number" db:"card_number"`
-	Balance    float64   `json:"balance" db:"balance"`
 	Status     string    `json:"status" db:"status"`
-	IssuedDate time.Time `json:"issued_date" db:"issued_date"`
-	ExpiryDate time.Time `json:"expiry_date" db:"expiry_date"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+	Listed     bool      `json:"listed" db:"-"`
+	ChangedAt  time.Time `json:"changed_at" db:"changed_at"`
 }
 
 type Transaction struct {
-	ID              int64     `json:"id" db:"id"`
-	CardID          uuid.UUID `json:"card_id" db:"card_id"`
-	GateID          uuid.UUID `json:"gate_id" db:"gate_id"`
-	TerminalID      uuid.UUID `json:"terminal_id" db:"terminal_id"`
-	TransactionType string    `json:"transaction_type" db:"transaction_type"`
-	Amount          *float64  `json:"amount" db:"amount"`
-	BalanceAfter    float64   `json:"balance_after" db:"balance_after"`
-	TransactionTime time.Time `json:"transaction_time" db:"transaction_time"`
+	ID              int64         `json:"id" db:"id"`
+	CardID          uuid.UUID     `json:"card_id" db:"card_id"`
+	GateID          uuid.UUID     `json:"gate_id" db:"gate_id"`
+	TerminalID      uuid.UUID     `json:"terminal_id" db:"terminal_id"`
+	TransactionType string        `json:"transaction_type" db:"transaction_type"`
+	Amount          *money.Amount `json:"amount" db:"amount"`
+	BalanceAfter    *money.Amount `json:"balance_after" db:"balance_after"`
+	TransactionTime time.Time     `json:"transaction_time" db:"transaction_time"`
+}
+
+// TapFailure is a tap rejected by a gate. CardID is empty when the card
+// number is not known to the system.
+type TapFailure struct {
+	ID         int64      `json:"id" db:"id"`
+	CardID     *uuid.UUID `json:"card_id" db:"card_id"`
+	CardNumber *string    `json:"card_number" db:"card_number"`
+	GateID     uuid.UUID  `json:"gate_id" db:"gate_id"`
+	TerminalID uuid.UUID  `json:"terminal_id" db:"terminal_id"`
+	TapType    string     `json:"tap_type" db:"tap_type"`
+	Reason     string     `json:"reason" db:"reason"`
+	OccurredAt time.Time  `json:"occurred_at" db:"occurred_at"`
+}
+
+// Tap is one card tap waiting for the alert engine: an accepted tap copied
+// from transactions or a rejected one from tap_failures. SourceID is the ID
+// of the row it was copied from.
+type Tap struct {
+	ID           int64         `json:"id" db:"id"`
+	SourceID     int64         `json:"source_id" db:"source_id"`
+	Accepted     bool          `json:"accepted" db:"accepted"`
+	CardID       *uuid.UUID    `json:"card_id" db:"card_id"`
+	CardNumber   *string       `json:"card_number" db:"card_number"`
+	GateID       uuid.UUID     `json:"gate_id" db:"gate_id"`
+	TerminalID   uuid.UUID     `json:"terminal_id" db:"terminal_id"`
+	TapType      string        `json:"tap_type" db:"tap_type"`
+	Amount       *money.Amount `json:"amount" db:"amount"`
+	BalanceAfter *money.Amount `json:"balance_after" db:"balance_after"`
+	Reason       *string       `json:"reason" db:"reason"`
+	TappedAt     time.Time     `json:"tapped_at" db:"tapped_at"`
+}
+
+type Alert struct {
+	ID          int64           `json:"id" db:"id"`
+	AlertType   string          `json:"alert_type" db:"alert_type"`
+	CardID      *uuid.UUID      `json:"card_id" db:"card_id"`
+	GateID      *uuid.UUID      `json:"gate_id" db:"gate_id"`
+	TerminalID  *uuid.UUID      `json:"terminal_id" db:"terminal_id"`
+	Details     json.RawMessage `json:"details" db:"details"`
+	OccurredAt  time.Time       `json:"occurred_at" db:"occurred_at"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt *time.Time      `json:"delivered_at" db:"delivered_at"`
 }
 
 type FareMatrix struct {
+	OriginTerminalID      uuid.UUID    `json:"origin_terminal_id" db:"origin_terminal_id"`
+	DestinationTerminalID uuid.UUID    `json:"destination_terminal_id" db:"destination_terminal_id"`
+	FareAmount            money.Amount `json:"fare_amount" db:"fare_amount"`
+	IsActive              bool         `json:"is_active" db:"is_active"`
+	CreatedAt             time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+type RouteDistance struct {
 	OriginTerminalID      uuid.UUID `json:"origin_terminal_id" db:"origin_terminal_id"`
 	DestinationTerminalID uuid.UUID `json:"destination_terminal_id" db:"destination_terminal_id"`
-	FareAmount            float64   `json:"fare_amount" db:"fare_amount"`
-	IsActive              bool      `json:"is_active" db:"is_active"`
-	CreatedAt             time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt             time.Time `json:"updated_at" db:"updated_at"`
+	DistanceKm            float64   `json:"distance_km" db:"distance_km"`
+}
+
+type FareZone struct {
+	Zone      int       `json:"zone" db:"zone"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type ZoneFare struct {
+	ZonesTravelled int          `json:"zones_travelled" db:"zones_travelled"`
+	FareAmount     money.Amount `json:"fare_amount" db:"fare_amount"`
+	CreatedAt      time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at" db:"updated_at"`
 }
 
 type Admin struct {
-	ID       int    `json:"id" db:"id"`
-	Username string `json:"username" db:"username"`
-	Password string `json:"-" db:"password"`
+	ID           int     `json:"id" db:"id"`
+	Username     string  `json:"username" db:"username"`
+	Password     string  `json:"-" db:"password"`
+	Role         string  `json:"role" db:"role"`
+	TOTPSecret   *string `json:"-" db:"totp_secret"`
+	TOTPEnabled  bool    `json:"totp_enabled" db:"totp_enabled"`
+	TOTPLastStep int64   `json:"-" db:"totp_last_step"`
+
+	MustChangePassword bool `json:"must_change_password" db:"must_change_password"`
+}
+
+type RefreshToken struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	FamilyID   uuid.UUID  `json:"family_id" db:"family_id"`
+	AdminID    int        `json:"admin_id" db:"admin_id"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+	ReplacedBy *uuid.UUID `json:"replaced_by" db:"replaced_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Actor is whoever performs a mutation, as recorded in the audit log. Jobs
+// act as the system actor, which has no role, address or request.
+type Actor struct {
+	Username  string
+	Role      string
+	IPAddress string
+	RequestID string
+}
+
+type AuditLog struct {
+	ID         int64           `json:"id" db:"id"`
+	Actor      string          `json:"actor" db:"actor"`
+	Action     string          `json:"action" db:"action"`
+	EntityType string          `json:"entity_type" db:"entity_type"`
+	EntityID   string          `json:"entity_id" db:"entity_id"`
+	Before     json.RawMessage `json:"before" db:"before"`
+	After      json.RawMessage `json:"after" db:"after"`
+	RequestID  *string         `json:"request_id" db:"request_id"`
+	IPAddress  *string         `json:"ip_address" db:"ip_address"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
 }
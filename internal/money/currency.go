@@ -0,0 +1,50 @@
+package money
+
+import (
+	"strings"
+)
+
+// Currency describes how amounts of a currency are rounded and displayed.
+// Decimals is the number of minor-unit digits in everyday use, at most
+// Scale.
+type Currency struct {
+	Code     string
+	Symbol   string
+	Decimals int
+}
+
+var (
+	IDR = Currency{Code: "IDR", Symbol: "Rp", Decimals: 0}
+	USD = Currency{Code: "USD", Symbol: "$", Decimals: 2}
+	SGD = Currency{Code: "SGD", Symbol: "S$", Decimals: 2}
+	MYR = Currency{Code: "MYR", Symbol: "RM", Decimals: 2}
+)
+
+var currencies = map[string]Currency{
+	IDR.Code: IDR,
+	USD.Code: USD,
+	SGD.Code: SGD,
+	MYR.Code: MYR,
+}
+
+// LookupCurrency returns the currency for an ISO 4217 code.
+func LookupCurrency(code string) (Currency, bool) {
+	currency, ok := currencies[strings.ToUpper(code)]
+	return currency, ok
+}
+
+// Unit is the smallest amount of the currency in everyday use, e.g. 1.00
+// for IDR and 0.01 for USD.
+func (c Currency) Unit() Amount {
+	unit := Amount(1)
+	for i := c.Decimals; i < Scale; i++ {
+		unit *= 10
+	}
+	return unit
+}
+
+// Valid reports whether a can be paid in the currency without fractions of
+// its smallest unit.
+func (c Currency) Valid(a Amount) bool {
+	return a%c.Unit() == 0
+}
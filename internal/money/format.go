@@ -0,0 +1,78 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Locale holds the separators used when displaying amounts.
+type Locale struct {
+	Tag     string
+	Group   string
+	Decimal string
+}
+
+var (
+	LocaleID = Locale{Tag: "id-ID", Group: ".", Decimal: ","}
+	LocaleEN = Locale{Tag: "en-US", Group: ",", Decimal: "."}
+)
+
+var locales = map[string]Locale{
+	strings.ToLower(LocaleID.Tag): LocaleID,
+	strings.ToLower(LocaleEN.Tag): LocaleEN,
+}
+
+// LookupLocale returns the locale for a BCP 47 tag such as "id-ID".
+func LookupLocale(tag string) (Locale, bool) {
+	locale, ok := locales[strings.ToLower(strings.ReplaceAll(tag, "_", "-"))]
+	return locale, ok
+}
+
+// Formatter renders amounts of one currency for one locale, e.g. "Rp12.500"
+// for IDR in id-ID and "$1,250.00" for USD in en-US.
+type Formatter struct {
+	Currency Currency
+	Locale   Locale
+}
+
+func NewFormatter(currency Currency, locale Locale) Formatter {
+	return Formatter{Currency: currency, Locale: locale}
+}
+
+func (f Formatter) Format(a Amount) string {
+	minor := int64(a)
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+
+	whole := groupDigits(fmt.Sprintf("%d", minor/minorPerUnit), f.Locale.Group)
+
+	fraction := ""
+	if f.Currency.Decimals > 0 {
+		digits := fmt.Sprintf("%0*d", Scale, minor%minorPerUnit)
+		fraction = f.Locale.Decimal + digits[:f.Currency.Decimals]
+	}
+
+	return sign + f.Currency.Symbol + whole + fraction
+}
+
+func groupDigits(digits, separator string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(separator)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
@@ -0,0 +1,205 @@
+// Package money represents monetary values as integer hundredths of the
+// currency unit so that fare and balance arithmetic is exact. Amounts carry
+// two decimal places, matching the NUMERIC(15,2) columns in the database;
+// currencies without minor units (IDR) only use whole multiples of 100.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// Scale is the number of decimal places kept in minor units.
+	Scale = 2
+
+	minorPerUnit = 100
+)
+
+var (
+	ErrInvalidAmount = errors.New("money: invalid amount")
+	ErrPrecision     = errors.New("money: amount has more than 2 decimal places")
+	ErrOverflow      = errors.New("money: amount out of range")
+)
+
+// Amount is a monetary value in hundredths, e.g. 1250 is 12.50.
+type Amount int64
+
+func FromMinor(minor int64) Amount {
+	return Amount(minor)
+}
+
+// FromFloat converts f to the nearest minor unit. It is meant for values
+// that are already approximate, never for amounts read from storage.
+func FromFloat(f float64) Amount {
+	return Amount(math.Round(f * minorPerUnit))
+}
+
+// Parse reads a decimal string such as "12.5" or "-3.25" exactly. At most
+// one leading sign is accepted.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+
+	negative := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return 0, ErrInvalidAmount
+	}
+	if len(fraction) > Scale {
+		if strings.Trim(fraction[Scale:], "0") != "" {
+			return 0, ErrPrecision
+		}
+		fraction = fraction[:Scale]
+	}
+	fraction += strings.Repeat("0", Scale-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, ErrOverflow
+		}
+		return 0, ErrInvalidAmount
+	}
+
+	if negative {
+		minor = -minor
+	}
+
+	return Amount(minor), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (a Amount) Minor() int64 {
+	return int64(a)
+}
+
+func (a Amount) Float64() float64 {
+	return float64(a) / minorPerUnit
+}
+
+// Add returns a + b, or ErrOverflow if the sum does not fit.
+func (a Amount) Add(b Amount) (Amount, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, ErrOverflow
+	}
+	return sum, nil
+}
+
+// MulFloat multiplies a by factor, rounding to the nearest minor unit. It
+// returns ErrOverflow if the product does not fit or factor is not finite.
+func (a Amount) MulFloat(factor float64) (Amount, error) {
+	product := math.Round(float64(a) * factor)
+	// 2^63 is exactly representable, every float64 below it fits in int64.
+	if math.IsNaN(product) || product >= math.MaxInt64 || product < math.MinInt64 {
+		return 0, ErrOverflow
+	}
+	return Amount(product), nil
+}
+
+// RoundUp rounds a up to the next multiple of step. A non-positive step
+// leaves a unchanged.
+func (a Amount) RoundUp(step Amount) Amount {
+	if step <= 0 {
+		return a
+	}
+
+	remainder := a % step
+	if remainder == 0 {
+		return a
+	}
+	if remainder < 0 {
+		return a - remainder
+	}
+	return a - remainder + step
+}
+
+func (a Amount) String() string {
+	minor := int64(a)
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/minorPerUnit, minor%minorPerUnit)
+}
+
+// MarshalJSON encodes the amount as a JSON number with two decimals.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number and parses it without going through
+// float64.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.ContainsAny(s, `"eE`) {
+		return ErrInvalidAmount
+	}
+
+	amount, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	*a = amount
+	return nil
+}
+
+// ScanNumeric implements pgtype.NumericScanner.
+func (a *Amount) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		return fmt.Errorf("cannot scan NULL into *money.Amount")
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return ErrInvalidAmount
+	}
+
+	minor := new(big.Int).Set(n.Int)
+	exp := int64(n.Exp) + Scale
+
+	ten := big.NewInt(10)
+	if exp > 0 {
+		minor.Mul(minor, new(big.Int).Exp(ten, big.NewInt(exp), nil))
+	} else if exp < 0 {
+		var remainder big.Int
+		minor.QuoRem(minor, new(big.Int).Exp(ten, big.NewInt(-exp), nil), &remainder)
+		if remainder.Sign() != 0 {
+			return ErrPrecision
+		}
+	}
+
+	if !minor.IsInt64() {
+		return ErrOverflow
+	}
+
+	*a = Amount(minor.Int64())
+	return nil
+}
+
+// NumericValue implements pgtype.NumericValuer.
+func (a Amount) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: big.NewInt(int64(a)), Exp: -Scale, Valid: true}, nil
+}
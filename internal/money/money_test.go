@@ -0,0 +1,234 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Amount
+		wantErr error
+	}{
+		{input: "0", want: 0},
+		{input: "12", want: 1200},
+		{input: "12.5", want: 1250},
+		{input: "12.50", want: 1250},
+		{input: "12.500", want: 1250},
+		{input: ".5", want: 50},
+		{input: "5.", want: 500},
+		{input: "-3.25", want: -325},
+		{input: "+3.25", want: 325},
+		{input: " 7 ", want: 700},
+		{input: "92233720368547758.07", want: math.MaxInt64},
+		{input: "", wantErr: ErrInvalidAmount},
+		{input: ".", wantErr: ErrInvalidAmount},
+		{input: "-", wantErr: ErrInvalidAmount},
+		{input: "-+5", wantErr: ErrInvalidAmount},
+		{input: "+-5", wantErr: ErrInvalidAmount},
+		{input: "--5", wantErr: ErrInvalidAmount},
+		{input: "5-", wantErr: ErrInvalidAmount},
+		{input: "1.2.3", wantErr: ErrInvalidAmount},
+		{input: "1.2x", wantErr: ErrInvalidAmount},
+		{input: "1,5", wantErr: ErrInvalidAmount},
+		{input: "1e3", wantErr: ErrInvalidAmount},
+		{input: "1.234", wantErr: ErrPrecision},
+		{input: "92233720368547758.08", wantErr: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Amount
+		want    Amount
+		wantErr error
+	}{
+		{name: "positive", a: 150, b: 275, want: 425},
+		{name: "negative", a: 150, b: -275, want: -125},
+		{name: "max", a: math.MaxInt64 - 1, b: 1, want: math.MaxInt64},
+		{name: "overflow", a: math.MaxInt64, b: 1, wantErr: ErrOverflow},
+		{name: "underflow", a: math.MinInt64, b: -1, wantErr: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Add(tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Add() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Add() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMulFloat(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  Amount
+		factor  float64
+		want    Amount
+		wantErr error
+	}{
+		{name: "whole factor", amount: 500, factor: 3, want: 1500},
+		{name: "rounds half away from zero", amount: 50000, factor: 12.34567, want: 617284},
+		{name: "zero", amount: 50000, factor: 0, want: 0},
+		{name: "negative factor", amount: 100, factor: -1.5, want: -150},
+		{name: "overflow", amount: math.MaxInt64 / 2, factor: 3, wantErr: ErrOverflow},
+		{name: "underflow", amount: math.MinInt64 / 2, factor: 3, wantErr: ErrOverflow},
+		{name: "infinite factor", amount: 1, factor: math.Inf(1), wantErr: ErrOverflow},
+		{name: "NaN factor", amount: 1, factor: math.NaN(), wantErr: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.amount.MulFloat(tt.factor)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MulFloat() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("MulFloat() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundUp(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount Amount
+		step   Amount
+		want   Amount
+	}{
+		{name: "already a multiple", amount: 1500, step: 500, want: 1500},
+		{name: "rounds up", amount: 1501, step: 500, want: 2000},
+		{name: "negative rounds toward zero", amount: -1501, step: 500, want: -1500},
+		{name: "zero step", amount: 1501, step: 0, want: 1501},
+		{name: "negative step", amount: 1501, step: -500, want: 1501},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.amount.RoundUp(tt.step); got != tt.want {
+				t.Errorf("RoundUp() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Amount
+		output  string
+		wantErr bool
+	}{
+		{name: "integer", input: "3000", want: 300000, output: "3000.00"},
+		{name: "decimal", input: "12.5", want: 1250, output: "12.50"},
+		{name: "negative", input: "-0.05", want: -5, output: "-0.05"},
+		{name: "string", input: `"12.5"`, wantErr: true},
+		{name: "exponent", input: "1e3", wantErr: true},
+		{name: "too precise", input: "0.001", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Amount
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal(%s) = %d, want %d", tt.input, got, tt.want)
+			}
+
+			output, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(output) != tt.output {
+				t.Errorf("Marshal() = %s, want %s", output, tt.output)
+			}
+		})
+	}
+
+	t.Run("null keeps the value", func(t *testing.T) {
+		got := Amount(100)
+		if err := json.Unmarshal([]byte("null"), &got); err != nil || got != 100 {
+			t.Errorf("Unmarshal(null) = (%d, %v), want (100, nil)", got, err)
+		}
+	})
+}
+
+func TestNumeric(t *testing.T) {
+	tests := []struct {
+		name    string
+		numeric pgtype.Numeric
+		want    Amount
+		wantErr error
+	}{
+		{name: "two decimals", numeric: pgtype.Numeric{Int: big.NewInt(1250), Exp: -2, Valid: true}, want: 1250},
+		{name: "whole", numeric: pgtype.Numeric{Int: big.NewInt(3), Exp: 3, Valid: true}, want: 300000},
+		{name: "trailing zeros", numeric: pgtype.Numeric{Int: big.NewInt(12500), Exp: -3, Valid: true}, want: 1250},
+		{name: "too precise", numeric: pgtype.Numeric{Int: big.NewInt(12501), Exp: -3, Valid: true}, wantErr: ErrPrecision},
+		{name: "too large", numeric: pgtype.Numeric{Int: big.NewInt(math.MaxInt64), Exp: 0, Valid: true}, wantErr: ErrOverflow},
+		{name: "NaN", numeric: pgtype.Numeric{NaN: true, Valid: true}, wantErr: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Amount
+			err := got.ScanNumeric(tt.numeric)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ScanNumeric() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ScanNumeric() = %d, want %d", got, tt.want)
+			}
+
+			numeric, err := got.NumericValue()
+			if err != nil {
+				t.Fatalf("NumericValue() error = %v", err)
+			}
+
+			var back Amount
+			if err := back.ScanNumeric(numeric); err != nil || back != got {
+				t.Errorf("round trip = (%d, %v), want (%d, nil)", back, err, got)
+			}
+		})
+	}
+
+	t.Run("NULL", func(t *testing.T) {
+		var got Amount
+		if err := got.ScanNumeric(pgtype.Numeric{}); err == nil {
+			t.Error("ScanNumeric(NULL) error = nil, want an error")
+		}
+	})
+}
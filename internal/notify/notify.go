@@ -0,0 +1,105 @@
+// Package notify delivers alerts raised by the alert engine. Notifier is the
+// extension point: the log and webhook notifiers are selected by
+// configuration, and Memory collects alerts in process for tests.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/google/uuid"
+)
+
+type Notifier interface {
+	Notify(ctx context.Context, alert *model.Alert) error
+}
+
+type logNotifier struct{}
+
+// NewLog writes each alert to the standard logger.
+func NewLog() Notifier {
+	return logNotifier{}
+}
+
+func (logNotifier) Notify(ctx context.Context, alert *model.Alert) error {
+	log.Printf("alert %d %s card=%s gate=%s at=%s details=%s",
+		alert.ID, alert.AlertType, optionalID(alert.CardID), optionalID(alert.GateID),
+		alert.OccurredAt.Format(time.RFC3339), alert.Details)
+	return nil
+}
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook posts each alert as JSON to url. Any status outside 2xx is
+// reported as an error.
+func NewWebhook(url string, timeout time.Duration) Notifier {
+	return &webhookNotifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, alert *model.Alert) error {
+	body, err := json.Marshal(map[string]any{"data": alert})
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Memory keeps every alert it is given. It is safe for concurrent use.
+type Memory struct {
+	mu     sync.Mutex
+	alerts []model.Alert
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Notify(ctx context.Context, alert *model.Alert) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.alerts = append(m.alerts, *alert)
+	return nil
+}
+
+// Alerts returns a copy of the alerts received so far.
+func (m *Memory) Alerts() []model.Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]model.Alert(nil), m.alerts...)
+}
+
+func optionalID(id *uuid.UUID) string {
+	if id == nil {
+		return "-"
+	}
+	return id.String()
+}
@@ -5,6 +5,7 @@ package provider
 
 import (
 	"github.com/aliffatulmf/mkp-eticket-service/internal/auth"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/handler"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
@@ -12,32 +13,184 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var auditSet = wire.NewSet(
+	repository.NewTransactor,
+	repository.NewAuditLogRepository,
+	service.NewAuditService,
+)
+
 var adminSet = wire.NewSet(
 	repository.NewAdminRepository,
+	repository.NewLoginAttemptRepository,
+	repository.NewRefreshTokenRepository,
 	service.NewAdminService,
 )
 
+var totpSet = wire.NewSet(
+	repository.NewRecoveryCodeRepository,
+	service.NewTOTPService,
+)
+
 func NewTerminalHandler(db *pgxpool.Pool) handler.TerminalHandler {
 	wire.Build(
+		auditSet,
 		repository.NewTerminalRepository,
+		repository.NewLineRepository,
 		service.NewTerminalService,
 		handler.NewTerminalHandler,
 	)
 	return nil
 }
 
-func NewAuthHandler(db *pgxpool.Pool, jwt auth.JWTService) handler.AuthHandler {
+func NewTerminalScheduleHandler(db *pgxpool.Pool, cfg *config.Config) handler.TerminalScheduleHandler {
+	wire.Build(
+		auditSet,
+		repository.NewTerminalScheduleRepository,
+		repository.NewTerminalRepository,
+		service.NewTerminalScheduleService,
+		handler.NewTerminalScheduleHandler,
+	)
+	return nil
+}
+
+func NewCardService(db *pgxpool.Pool, cfg *config.Config) service.CardService {
+	wire.Build(
+		auditSet,
+		repository.NewCardRepository,
+		service.NewCardService,
+	)
+	return nil
+}
+
+func NewCardHandler(db *pgxpool.Pool, cfg *config.Config) handler.CardHandler {
+	wire.Build(
+		auditSet,
+		repository.NewCardRepository,
+		service.NewCardService,
+		handler.NewCardHandler,
+	)
+	return nil
+}
+
+func NewCardHolderHandler(db *pgxpool.Pool, cfg *config.Config) handler.CardHolderHandler {
+	wire.Build(
+		auditSet,
+		repository.NewCardHolderRepository,
+		service.NewCardHolderService,
+		handler.NewCardHolderHandler,
+	)
+	return nil
+}
+
+func NewAlertService(db *pgxpool.Pool, cfg *config.Config) service.AlertService {
+	wire.Build(
+		repository.NewAlertRepository,
+		repository.NewTerminalRepository,
+		service.NewAlertNotifier,
+		service.NewAlertService,
+	)
+	return nil
+}
+
+func NewAlertHandler(db *pgxpool.Pool, cfg *config.Config) handler.AlertHandler {
+	wire.Build(
+		repository.NewAlertRepository,
+		repository.NewTerminalRepository,
+		service.NewAlertNotifier,
+		service.NewAlertService,
+		handler.NewAlertHandler,
+	)
+	return nil
+}
+
+func NewHotlistHandler(db *pgxpool.Pool, cfg *config.Config) handler.HotlistHandler {
 	wire.Build(
+		repository.NewHotlistRepository,
+		service.NewHotlistService,
+		handler.NewHotlistHandler,
+	)
+	return nil
+}
+
+func NewGateService(db *pgxpool.Pool) service.GateService {
+	wire.Build(
+		auditSet,
+		repository.NewGateRepository,
+		service.NewGateService,
+	)
+	return nil
+}
+
+func NewGateHandler(db *pgxpool.Pool) handler.GateHandler {
+	wire.Build(
+		auditSet,
+		repository.NewGateRepository,
+		service.NewGateService,
+		handler.NewGateHandler,
+	)
+	return nil
+}
+
+func NewAuthHandler(db *pgxpool.Pool, cfg *config.Config, jwt auth.JWTService) handler.AuthHandler {
+	wire.Build(
+		auditSet,
 		adminSet,
+		service.NewRefreshTokenService,
+		totpSet,
 		handler.NewAuthHandler,
 	)
 	return nil
 }
 
-func NewAdminHandler(db *pgxpool.Pool) handler.AdminHandler {
+func NewAdminHandler(db *pgxpool.Pool, cfg *config.Config) handler.AdminHandler {
 	wire.Build(
+		auditSet,
 		adminSet,
 		handler.NewAdminHandler,
 	)
 	return nil
 }
+
+func NewTOTPHandler(db *pgxpool.Pool, cfg *config.Config) handler.TOTPHandler {
+	wire.Build(
+		auditSet,
+		repository.NewAdminRepository,
+		repository.NewLoginAttemptRepository,
+		totpSet,
+		handler.NewTOTPHandler,
+	)
+	return nil
+}
+
+func NewAuditHandler(db *pgxpool.Pool) handler.AuditHandler {
+	wire.Build(
+		auditSet,
+		handler.NewAuditHandler,
+	)
+	return nil
+}
+
+func NewFareHandler(db *pgxpool.Pool, cfg *config.Config) handler.FareHandler {
+	wire.Build(
+		auditSet,
+		repository.NewFareRepository,
+		repository.NewTerminalRepository,
+		repository.NewRouteDistanceRepository,
+		repository.NewFareZoneRepository,
+		service.NewFareCalculator,
+		service.NewFareService,
+		handler.NewFareHandler,
+	)
+	return nil
+}
+
+func NewLineHandler(db *pgxpool.Pool) handler.LineHandler {
+	wire.Build(
+		auditSet,
+		repository.NewLineRepository,
+		repository.NewTerminalRepository,
+		service.NewLineService,
+		handler.NewLineHandler,
+	)
+	return nil
+}
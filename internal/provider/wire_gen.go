@@ -8,6 +8,7 @@ package provider
 
 import (
 	"github.com/aliffatulmf/mkp-eticket-service/internal/auth"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/handler"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/service"
@@ -19,25 +20,173 @@ import (
 
 func NewTerminalHandler(db *pgxpool.Pool) handler.TerminalHandler {
 	terminalRepository := repository.NewTerminalRepository(db)
-	terminalService := service.NewTerminalService(terminalRepository)
+	lineRepository := repository.NewLineRepository(db)
+	transactor := repository.NewTransactor(db)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditService := service.NewAuditService(auditLogRepository)
+	terminalService := service.NewTerminalService(terminalRepository, lineRepository, transactor, auditService)
 	terminalHandler := handler.NewTerminalHandler(terminalService)
 	return terminalHandler
 }
 
-func NewAuthHandler(db *pgxpool.Pool, jwt auth.JWTService) handler.AuthHandler {
+func NewTerminalScheduleHandler(db *pgxpool.Pool, cfg *config.Config) handler.TerminalScheduleHandler {
+	terminalScheduleRepository := repository.NewTerminalScheduleRepository(db)
+	terminalRepository := repository.NewTerminalRepository(db)
+	transactor := repository.NewTransactor(db)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditService := service.NewAuditService(auditLogRepository)
+	terminalScheduleService := service.NewTerminalScheduleService(terminalScheduleRepository, terminalRepository, transactor, auditService, cfg)
+	terminalScheduleHandler := handler.NewTerminalScheduleHandler(terminalScheduleService)
+	return terminalScheduleHandler
+}
+
+func NewCardService(db *pgxpool.Pool, cfg *config.Config) service.CardService {
+	cardRepository := repository.NewCardRepository(db)
+	transactor := repository.NewTransactor(db)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditService := service.NewAuditService(auditLogRepository)
+	cardService := service.NewCardService(cardRepository, transactor, auditService, cfg)
+	return cardService
+}
+
+func NewCardHandler(db *pgxpool.Pool, cfg *config.Config) handler.CardHandler {
+	cardRepository := repository.NewCardRepository(db)
+	transactor := repository.NewTransactor(db)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditService := service.NewAuditService(auditLogRepository)
+	cardService := service.NewCardService(cardRepository, transactor, auditService, cfg)
+	cardHandler := handler.NewCardHandler(cardService)
+	return cardHandler
+}
+
+func NewCardHolderHandler(db *pgxpool.Pool, cfg *config.Config) handler.CardHolderHandler {
+	cardHolderRepository := repository.NewCardHolderRepository(db)
+	transactor := repository.NewTransactor(db)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditService := service.NewAuditService(auditLogRepository)
+	cardHolderService := service.NewCardHolderService(cardHolderRepository, transactor, auditService, cfg)
+	cardHolderHandler := handler.NewCardHolderHandler(cardHolderService)
+	return cardHolderHandler
+}
+
+func NewAlertService(db *pgxpool.Pool, cfg *config.Config) service.AlertService {
+	alertRepository := repository.NewAlertRepository(db)
+	terminalRepository := repository.NewTerminalRepository(db)
+	notifier := service.NewAlertNotifier(cfg)
+	alertService := service.NewAlertService(alertRepository, terminalRepository, notifier, cfg)
+	return alertService
+}
+
+func NewAlertHandler(db *pgxpool.Pool, cfg *config.Config) handler.AlertHandler {
+	alertRepository := repository.NewAlertRepository(db)
+	terminalRepository := repository.NewTerminalRepository(db)
+	notifier := service.NewAlertNotifier(cfg)
+	alertService := service.NewAlertService(alertRepository, terminalRepository, notifier, cfg)
+	alertHandler := handler.NewAlertHandler(alertService)
+	return alertHandler
+}
+
+func NewHotlistHandler(db *pgxpool.Pool, cfg *config.Config) handler.HotlistHandler {
+	hotlistRepository := repository.NewHotlistRepository(db)
+	hotlistService := service.NewHotlistService(hotlistRepository, cfg)
+	hotlistHandler := handler.NewHotlistHandler(hotlistService)
+	return hotlistHandler
+}
+
+func NewGateService(db *pgxpool.Pool) service.GateService {
+	gateRepository := repository.NewGateRepository(db)
+	transactor := repository.NewTransactor(db)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditService := service.NewAuditService(auditLogRepository)
+	gateService := service.NewGateService(gateRepository, transactor, auditService)
+	return gateService
+}
+
+func NewGateHandler(db *pgxpool.Pool) handler.GateHandler {
+	gateRepository := repository.NewGateRepository(db)
+	transactor := repository.NewTransactor(db)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditService := service.NewAuditService(auditLogRepository)
+	gateService := service.NewGateService(gateRepository, transactor, auditService)
+	gateHandler := handler.NewGateHandler(gateService)
+	return gateHandler
+}
+
+func NewAuthHandler(db *pgxpool.Pool, cfg *config.Config, jwt auth.JWTService) handler.AuthHandler {
 	adminRepository := repository.NewAdminRepository(db)
-	adminService := service.NewAdminService(adminRepository)
-	authHandler := handler.NewAuthHandler(adminService, jwt)
+	loginAttemptRepository := repository.NewLoginAttemptRepository(db)
+	refreshTokenRepository := repository.NewRefreshTokenRepository(db)
+	transactor := repository.NewTransactor(db)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditService := service.NewAuditService(auditLogRepository)
+	adminService := service.NewAdminService(adminRepository, loginAttemptRepository, refreshTokenRepository, transactor, auditService, cfg)
+	refreshTokenService := service.NewRefreshTokenService(refreshTokenRepository, adminRepository, jwt)
+	recoveryCodeRepository := repository.NewRecoveryCodeRepository(db)
+	totpService := service.NewTOTPService(adminRepository, recoveryCodeRepository, loginAttemptRepository, transactor, auditService, cfg)
+	authHandler := handler.NewAuthHandler(adminService, refreshTokenService, totpService, jwt)
 	return authHandler
 }
 
-func NewAdminHandler(db *pgxpool.Pool) handler.AdminHandler {
+func NewAdminHandler(db *pgxpool.Pool, cfg *config.Config) handler.AdminHandler {
 	adminRepository := repository.NewAdminRepository(db)
-	adminService := service.NewAdminService(adminRepository)
+	loginAttemptRepository := repository.NewLoginAttemptRepository(db)
+	refreshTokenRepository := repository.NewRefreshTokenRepository(db)
+	transactor := repository.NewTransactor(db)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditService := service.NewAuditService(auditLogRepository)
+	adminService := service.NewAdminService(adminRepository, loginAttemptRepository, refreshTokenRepository, transactor, auditService, cfg)
 	adminHandler := handler.NewAdminHandler(adminService)
 	return adminHandler
 }
 
+func NewTOTPHandler(db *pgxpool.Pool, cfg *config.Config) handler.TOTPHandler {
+	adminRepository := repository.NewAdminRepository(db)
+	recoveryCodeRepository := repository.NewRecoveryCodeRepository(db)
+	loginAttemptRepository := repository.NewLoginAttemptRepository(db)
+	transactor := repository.NewTransactor(db)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditService := service.NewAuditService(auditLogRepository)
+	totpService := service.NewTOTPService(adminRepository, recoveryCodeRepository, loginAttemptRepository, transactor, auditService, cfg)
+	totpHandler := handler.NewTOTPHandler(totpService)
+	return totpHandler
+}
+
+func NewAuditHandler(db *pgxpool.Pool) handler.AuditHandler {
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditService := service.NewAuditService(auditLogRepository)
+	auditHandler := handler.NewAuditHandler(auditService)
+	return auditHandler
+}
+
+func NewFareHandler(db *pgxpool.Pool, cfg *config.Config) handler.FareHandler {
+	fareRepository := repository.NewFareRepository(db)
+	terminalRepository := repository.NewTerminalRepository(db)
+	routeDistanceRepository := repository.NewRouteDistanceRepository(db)
+	fareZoneRepository := repository.NewFareZoneRepository(db)
+	fareCalculator := service.NewFareCalculator(fareRepository, fareZoneRepository, terminalRepository, cfg)
+	transactor := repository.NewTransactor(db)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditService := service.NewAuditService(auditLogRepository)
+	fareService := service.NewFareService(fareRepository, terminalRepository, routeDistanceRepository, fareZoneRepository, fareCalculator, transactor, auditService, cfg)
+	fareHandler := handler.NewFareHandler(fareService)
+	return fareHandler
+}
+
+func NewLineHandler(db *pgxpool.Pool) handler.LineHandler {
+	lineRepository := repository.NewLineRepository(db)
+	terminalRepository := repository.NewTerminalRepository(db)
+	transactor := repository.NewTransactor(db)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	auditService := service.NewAuditService(auditLogRepository)
+	lineService := service.NewLineService(lineRepository, terminalRepository, transactor, auditService)
+	lineHandler := handler.NewLineHandler(lineService)
+	return lineHandler
+}
+
 // wire.go:
 
-var adminSet = wire.NewSet(repository.NewAdminRepository, service.NewAdminService)
+var auditSet = wire.NewSet(repository.NewTransactor, repository.NewAuditLogRepository, service.NewAuditService)
+
+var adminSet = wire.NewSet(repository.NewAdminRepository, repository.NewLoginAttemptRepository, repository.NewRefreshTokenRepository, service.NewAdminService)
+
+var totpSet = wire.NewSet(repository.NewRecoveryCodeRepository, service.NewTOTPService)
@@ -3,18 +3,31 @@ package repository
 import (
 	"context"
 	"fmt"
+	"slices"
 
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/auth"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type AdminRepository interface {
+	List(ctx context.Context) ([]model.Admin, error)
 	FindByUsername(ctx context.Context, username string) (*model.Admin, error)
 	FindByID(ctx context.Context, id int) (*model.Admin, error)
 	Create(ctx context.Context, admin *model.Admin) error
+	Update(ctx context.Context, admin *model.Admin) error
+	UpdatePassword(ctx context.Context, id int, password string, mustChange bool) error
+	UpdateTOTP(ctx context.Context, id int, secret *string, enabled bool) error
+	EnableTOTP(ctx context.Context, id int, secret string, step int64, codeHashes []string) error
+	DisableTOTP(ctx context.Context, id int) error
+	AdvanceTOTPStep(ctx context.Context, id int, step int64) (bool, error)
 	Delete(ctx context.Context, id int) error
 }
 
+var ErrLastSuperAdmin = apperror.Conflict("cannot remove the last superadmin")
+
 type adminRepository struct {
 	db *pgxpool.Pool
 }
@@ -23,42 +36,121 @@ func NewAdminRepository(db *pgxpool.Pool) AdminRepository {
 	return &adminRepository{db: db}
 }
 
+func (r *adminRepository) List(ctx context.Context) ([]model.Admin, error) {
+	query := `SELECT id, username, password, role, totp_secret, totp_enabled, totp_last_step, must_change_password FROM admins ORDER BY id`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query admins: %w", err)
+	}
+	defer rows.Close()
+
+	admins, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Admin])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return admins, nil
+}
+
 func (r *adminRepository) FindByUsername(ctx context.Context, username string) (*model.Admin, error) {
-	query := `SELECT id, username, password FROM admins WHERE username = $1`
+	query := `SELECT id, username, password, role, totp_secret, totp_enabled, totp_last_step, must_change_password FROM admins WHERE username = $1`
 
 	var admin model.Admin
-	err := r.db.QueryRow(ctx, query, username).Scan(&admin.ID, &admin.Username, &admin.Password)
+	err := conn(ctx, r.db).QueryRow(ctx, query, username).Scan(
+		&admin.ID, &admin.Username, &admin.Password, &admin.Role,
+		&admin.TOTPSecret, &admin.TOTPEnabled, &admin.TOTPLastStep, &admin.MustChangePassword,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get admin by username: %w", err)
+		return nil, fmt.Errorf("failed to get admin by username: %w", mapError(err))
 	}
 
 	return &admin, nil
 }
 
 func (r *adminRepository) FindByID(ctx context.Context, id int) (*model.Admin, error) {
-	query := `SELECT id, username, password FROM admins WHERE id = $1`
+	query := `SELECT id, username, password, role, totp_secret, totp_enabled, totp_last_step, must_change_password FROM admins WHERE id = $1`
 
 	var admin model.Admin
-	err := r.db.QueryRow(ctx, query, id).Scan(&admin.ID, &admin.Username, &admin.Password)
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
+		&admin.ID, &admin.Username, &admin.Password, &admin.Role,
+		&admin.TOTPSecret, &admin.TOTPEnabled, &admin.TOTPLastStep, &admin.MustChangePassword,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get admin by id: %w", err)
+		return nil, fmt.Errorf("failed to get admin by id: %w", mapError(err))
 	}
 
 	return &admin, nil
 }
 
 func (r *adminRepository) Create(ctx context.Context, admin *model.Admin) error {
-	tx, err := r.db.Begin(ctx)
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO admins (username, password, role, must_change_password) VALUES ($1, $2, $3, $4) RETURNING id`
+
+	err = tx.QueryRow(ctx, query, admin.Username, admin.Password, admin.Role, admin.MustChangePassword).Scan(&admin.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create admin: %w", mapWriteError(err))
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *adminRepository) Update(ctx context.Context, admin *model.Admin) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if admin.Role != auth.RoleSuperAdmin {
+		if err := ensureSuperAdminRemains(ctx, tx, admin.ID); err != nil {
+			return err
+		}
+	}
+
+	query := `UPDATE admins SET username = $2, password = $3, role = $4, must_change_password = $5 WHERE id = $1`
+
+	result, err := tx.Exec(ctx, query, admin.ID, admin.Username, admin.Password, admin.Role, admin.MustChangePassword)
+	if err != nil {
+		return fmt.Errorf("failed to update admin: %w", mapWriteError(err))
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("admin with id %d: %w", admin.ID, ErrNotFound)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *adminRepository) UpdatePassword(ctx context.Context, id int, password string, mustChange bool) error {
+	tx, err := begin(ctx, r.db)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	query := `INSERT INTO admins (username, password) VALUES ($1, $2) RETURNING id`
+	query := `UPDATE admins SET password = $2, must_change_password = $3 WHERE id = $1`
 
-	err = tx.QueryRow(ctx, query, admin.Username, admin.Password).Scan(&admin.ID)
+	result, err := tx.Exec(ctx, query, id, password, mustChange)
 	if err != nil {
-		return fmt.Errorf("failed to create admin: %w", err)
+		return fmt.Errorf("failed to update admin password: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("admin with id %d: %w", id, ErrNotFound)
 	}
 
 	if err = tx.Commit(ctx); err != nil {
@@ -68,23 +160,120 @@ func (r *adminRepository) Create(ctx context.Context, admin *model.Admin) error
 	return nil
 }
 
+func (r *adminRepository) UpdateTOTP(ctx context.Context, id int, secret *string, enabled bool) error {
+	query := `UPDATE admins SET totp_secret = $2, totp_enabled = $3, totp_last_step = 0 WHERE id = $1`
+
+	result, err := conn(ctx, r.db).Exec(ctx, query, id, secret, enabled)
+	if err != nil {
+		return fmt.Errorf("failed to update admin totp: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("admin with id %d: %w", id, ErrNotFound)
+	}
+
+	return nil
+}
+
+// EnableTOTP turns on two-factor login for the enrolled secret and replaces
+// the recovery codes in one transaction. step is the time step of the code
+// that confirmed the secret, so that code cannot be used again to log in.
+// ErrStale is returned when two-factor was enabled or re-enrolled meanwhile.
+func (r *adminRepository) EnableTOTP(ctx context.Context, id int, secret string, step int64, codeHashes []string) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE admins SET totp_enabled = TRUE, totp_last_step = $3
+		WHERE id = $1 AND totp_secret = $2 AND NOT totp_enabled`
+
+	result, err := tx.Exec(ctx, query, id, secret, step)
+	if err != nil {
+		return fmt.Errorf("failed to update admin totp: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("admin with id %d: %w", id, ErrStale)
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, id, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DisableTOTP turns off two-factor login and drops the secret and the
+// recovery codes in one transaction.
+func (r *adminRepository) DisableTOTP(ctx context.Context, id int) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE admins SET totp_secret = NULL, totp_enabled = FALSE, totp_last_step = 0 WHERE id = $1`
+
+	result, err := tx.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to update admin totp: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("admin with id %d: %w", id, ErrNotFound)
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, id, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// AdvanceTOTPStep records step as the last accepted TOTP step. It reports
+// false when the step was already used, preventing code replay.
+func (r *adminRepository) AdvanceTOTPStep(ctx context.Context, id int, step int64) (bool, error) {
+	query := `UPDATE admins SET totp_last_step = $2 WHERE id = $1 AND totp_last_step < $2`
+
+	result, err := conn(ctx, r.db).Exec(ctx, query, id, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to update admin totp step: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
 func (r *adminRepository) Delete(ctx context.Context, id int) error {
-	tx, err := r.db.Begin(ctx)
+	tx, err := begin(ctx, r.db)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
+	if err := ensureSuperAdminRemains(ctx, tx, id); err != nil {
+		return err
+	}
+
 	query := `DELETE FROM admins WHERE id = $1`
 
 	result, err := tx.Exec(ctx, query, id)
 	if err != nil {
-		return fmt.Errorf("failed to delete admin: %w", err)
+		return fmt.Errorf("failed to delete admin: %w", mapError(err))
 	}
 
 	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("admin with id %d not found", id)
+		return fmt.Errorf("admin with id %d: %w", id, ErrNotFound)
 	}
 
 	if err = tx.Commit(ctx); err != nil {
@@ -93,3 +282,24 @@ func (r *adminRepository) Delete(ctx context.Context, id int) error {
 
 	return nil
 }
+
+// ensureSuperAdminRemains fails when the admin is the only superadmin left.
+// The superadmin rows stay locked until tx ends, so concurrent demotions and
+// deletes are checked one after another instead of against the same count.
+func ensureSuperAdminRemains(ctx context.Context, tx pgx.Tx, id int) error {
+	rows, err := tx.Query(ctx, `SELECT id FROM admins WHERE role = $1 ORDER BY id FOR UPDATE`, auth.RoleSuperAdmin)
+	if err != nil {
+		return fmt.Errorf("failed to lock superadmins: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	if slices.Contains(ids, id) && len(ids) <= 1 {
+		return ErrLastSuperAdmin
+	}
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AlertRepository interface {
+	List(ctx context.Context, filter *model.AlertFilter) ([]model.Alert, error)
+	SetQueueEnabled(ctx context.Context, enabled bool) error
+	Pending(ctx context.Context, afterID int64, limit, maxAttempts int) ([]model.Tap, error)
+	Complete(ctx context.Context, tapID int64, alerts []model.Alert) (bool, error)
+	Fail(ctx context.Context, tapID int64, reason string) error
+	Undelivered(ctx context.Context, afterID int64, limit, maxAttempts int) ([]model.Alert, error)
+	MarkDelivered(ctx context.Context, id int64, deliveredAt time.Time) error
+	FailDelivery(ctx context.Context, id int64, reason string) error
+	CountFailures(ctx context.Context, gateID uuid.UUID, from, to time.Time) (int, error)
+	HasGateAlert(ctx context.Context, alertType string, gateID uuid.UUID, from, to time.Time) (bool, error)
+	PreviousTransaction(ctx context.Context, cardID uuid.UUID, transactionID int64, before time.Time) (*model.Transaction, error)
+}
+
+type alertRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAlertRepository(db *pgxpool.Pool) AlertRepository {
+	return &alertRepository{db: db}
+}
+
+func (r *alertRepository) List(ctx context.Context, filter *model.AlertFilter) ([]model.Alert, error) {
+	var q listQuery
+
+	if filter.AlertType != "" {
+		q.where("alert_type = $%d", filter.AlertType)
+	}
+	if filter.CardID != nil {
+		q.where("card_id = $%d", *filter.CardID)
+	}
+	if filter.From != nil {
+		q.where("occurred_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		q.where("occurred_at < $%d", *filter.To)
+	}
+
+	q.orderBy = "id DESC"
+	query, args := q.page(`SELECT id, alert_type, card_id, gate_id, terminal_id, details, occurred_at, created_at, delivered_at FROM alerts`, filter.Limit, filter.Offset)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Alert])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// SetQueueEnabled switches the triggers that copy taps into the queue.
+// Disabling it also empties the queue, since nothing would evaluate it.
+func (r *alertRepository) SetQueueEnabled(ctx context.Context, enabled bool) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE alert_settings SET queue_enabled = $1`, enabled); err != nil {
+		return fmt.Errorf("failed to update alert settings: %w", err)
+	}
+
+	if !enabled {
+		if _, err := tx.Exec(ctx, `DELETE FROM tap_queue`); err != nil {
+			return fmt.Errorf("failed to clear tap queue: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Pending returns up to limit queued taps after afterID that have been tried
+// fewer than maxAttempts times, oldest first. The rows are not locked, so the
+// caller can look up whatever it needs before completing each tap.
+func (r *alertRepository) Pending(ctx context.Context, afterID int64, limit, maxAttempts int) ([]model.Tap, error) {
+	query := `SELECT id, source_id, accepted, card_id, card_number, gate_id, terminal_id, tap_type::text AS tap_type,
+			amount, balance_after, reason, tapped_at
+		FROM tap_queue
+		WHERE id > $1 AND attempts < $3
+		ORDER BY id
+		LIMIT $2`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, afterID, limit, maxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tap queue: %w", err)
+	}
+	defer rows.Close()
+
+	taps, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Tap])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return taps, nil
+}
+
+// Complete removes an evaluated tap from the queue and stores its alerts in
+// one transaction, setting their IDs. It returns false without storing
+// anything when a concurrent run has already completed the tap.
+func (r *alertRepository) Complete(ctx context.Context, tapID int64, alerts []model.Alert) (bool, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `DELETE FROM tap_queue WHERE id = $1`, tapID)
+	if err != nil {
+		return false, fmt.Errorf("failed to dequeue tap: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	query := `INSERT INTO alerts (alert_type, card_id, gate_id, terminal_id, details, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	for i := range alerts {
+		alert := &alerts[i]
+		err := tx.QueryRow(ctx, query,
+			alert.AlertType, alert.CardID, alert.GateID, alert.TerminalID, alert.Details, alert.OccurredAt,
+		).Scan(&alert.ID, &alert.CreatedAt)
+		if err != nil {
+			return false, fmt.Errorf("failed to create alert: %w", mapWriteError(err))
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// Fail records a failed evaluation of the tap, which stays queued.
+func (r *alertRepository) Fail(ctx context.Context, tapID int64, reason string) error {
+	query := `UPDATE tap_queue SET attempts = attempts + 1, last_error = $2 WHERE id = $1`
+
+	if _, err := conn(ctx, r.db).Exec(ctx, query, tapID, reason); err != nil {
+		return fmt.Errorf("failed to update tap queue: %w", err)
+	}
+
+	return nil
+}
+
+// Undelivered returns up to limit alerts after afterID that are not yet
+// delivered and have failed delivery fewer than maxAttempts times.
+func (r *alertRepository) Undelivered(ctx context.Context, afterID int64, limit, maxAttempts int) ([]model.Alert, error) {
+	query := `SELECT id, alert_type, card_id, gate_id, terminal_id, details, occurred_at, created_at, delivered_at
+		FROM alerts
+		WHERE delivered_at IS NULL AND id > $1 AND delivery_attempts < $3
+		ORDER BY id
+		LIMIT $2`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, afterID, limit, maxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Alert])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return alerts, nil
+}
+
+func (r *alertRepository) MarkDelivered(ctx context.Context, id int64, deliveredAt time.Time) error {
+	query := `UPDATE alerts SET delivered_at = $2, last_error = NULL WHERE id = $1`
+
+	if _, err := conn(ctx, r.db).Exec(ctx, query, id, deliveredAt); err != nil {
+		return fmt.Errorf("failed to update alert: %w", err)
+	}
+
+	return nil
+}
+
+// FailDelivery records a failed delivery; the alert is retried on a later run.
+func (r *alertRepository) FailDelivery(ctx context.Context, id int64, reason string) error {
+	query := `UPDATE alerts SET delivery_attempts = delivery_attempts + 1, last_error = $2 WHERE id = $1`
+
+	if _, err := conn(ctx, r.db).Exec(ctx, query, id, reason); err != nil {
+		return fmt.Errorf("failed to update alert: %w", err)
+	}
+
+	return nil
+}
+
+// CountFailures counts the taps rejected at the gate in (from, to].
+func (r *alertRepository) CountFailures(ctx context.Context, gateID uuid.UUID, from, to time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM tap_failures WHERE gate_id = $1 AND occurred_at > $2 AND occurred_at <= $3`
+
+	var count int
+	if err := conn(ctx, r.db).QueryRow(ctx, query, gateID, from, to).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count tap failures: %w", err)
+	}
+
+	return count, nil
+}
+
+// HasGateAlert reports whether an alert of the type was raised for the gate
+// with occurred_at in (from, to].
+func (r *alertRepository) HasGateAlert(ctx context.Context, alertType string, gateID uuid.UUID, from, to time.Time) (bool, error) {
+	query := `SELECT EXISTS (
+		SELECT 1 FROM alerts WHERE alert_type = $1 AND gate_id = $2 AND occurred_at > $3 AND occurred_at <= $4
+	)`
+
+	var exists bool
+	if err := conn(ctx, r.db).QueryRow(ctx, query, alertType, gateID, from, to).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check alerts: %w", err)
+	}
+
+	return exists, nil
+}
+
+// PreviousTransaction returns the latest transaction of the card up to
+// before, other than transactionID, or nil when there is none.
+func (r *alertRepository) PreviousTransaction(ctx context.Context, cardID uuid.UUID, transactionID int64, before time.Time) (*model.Transaction, error) {
+	query := `SELECT id, card_id, gate_id, terminal_id, transaction_type::text, amount, balance_after, transaction_time
+		FROM transactions
+		WHERE card_id = $1 AND id <> $2 AND transaction_time <= $3
+		ORDER BY transaction_time DESC, id DESC
+		LIMIT 1`
+
+	var transaction model.Transaction
+	err := conn(ctx, r.db).QueryRow(ctx, query, cardID, transactionID, before).Scan(
+		&transaction.ID, &transaction.CardID, &transaction.GateID, &transaction.TerminalID,
+		&transaction.TransactionType, &transaction.Amount, &transaction.BalanceAfter, &transaction.TransactionTime,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous transaction: %w", err)
+	}
+
+	return &transaction, nil
+}
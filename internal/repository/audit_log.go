@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditLogRepository interface {
+	Create(ctx context.Context, log *model.AuditLog) error
+	List(ctx context.Context, filter *model.AuditLogFilter) ([]model.AuditLog, error)
+}
+
+type auditLogRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditLogRepository(db *pgxpool.Pool) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, log *model.AuditLog) error {
+	query := `INSERT INTO audit_logs (actor, action, entity_type, entity_id, before, after, request_id, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		log.Actor, log.Action, log.EntityType, log.EntityID,
+		log.Before, log.After, log.RequestID, log.IPAddress,
+	).Scan(&log.ID, &log.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return nil
+}
+
+func (r *auditLogRepository) List(ctx context.Context, filter *model.AuditLogFilter) ([]model.AuditLog, error) {
+	var q listQuery
+
+	if filter.Actor != "" {
+		q.where("actor = $%d", filter.Actor)
+	}
+	if filter.Action != "" {
+		q.where("action = $%d", filter.Action)
+	}
+	if filter.EntityType != "" {
+		q.where("entity_type = $%d", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		q.where("entity_id = $%d", filter.EntityID)
+	}
+	if filter.From != nil {
+		q.where("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		q.where("created_at < $%d", *filter.To)
+	}
+
+	q.orderBy = "id DESC"
+	query, args := q.page(`SELECT id, actor, action, entity_type, entity_id, before, after, request_id, ip_address, created_at FROM audit_logs`, filter.Limit, filter.Offset)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	logs, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.AuditLog])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return logs, nil
+}
@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/money"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CardRepository interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Card, error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, status string, updatedAt time.Time) error
+	ExpireDue(ctx context.Context, today time.Time) ([]uuid.UUID, error)
+	Extend(ctx context.Context, card *model.Card) error
+	Replace(ctx context.Context, oldID uuid.UUID, card *model.Card, reason string, allowBlocked bool) ([]model.LedgerEntry, error)
+	Transfer(ctx context.Context, sourceID, targetID uuid.UUID, reason string, at time.Time) ([]model.LedgerEntry, error)
+}
+
+var (
+	ErrCardUnavailable = apperror.Conflict("card is replaced or not active")
+	ErrCardBlocked     = apperror.Conflict("card is blocked")
+	ErrOpenTrip        = apperror.Conflict("card has an open trip")
+)
+
+type cardRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCardRepository(db *pgxpool.Pool) CardRepository {
+	return &cardRepository{db: db}
+}
+
+func (r *cardRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Card, error) {
+	query := `SELECT id, card_number, balance, status::text, issued_date, expiry_date, replaced_by, holder_id, created_at, updated_at FROM cards WHERE id = $1`
+
+	var card model.Card
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
+		&card.ID, &card.CardNumber, &card.Balance, &card.Status,
+		&card.IssuedDate, &card.ExpiryDate, &card.ReplacedBy, &card.HolderID, &card.CreatedAt, &card.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get card: %w", mapError(err))
+	}
+
+	return &card, nil
+}
+
+func (r *cardRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string, updatedAt time.Time) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE cards SET status = $2::card_status, updated_at = $3 WHERE id = $1`
+
+	result, err := tx.Exec(ctx, query, id, status, updatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update card status: %w", mapWriteError(err))
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("card with id %s: %w", id, ErrNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ExpireDue marks active cards whose expiry date is before today as expired
+// and returns their IDs.
+func (r *cardRepository) ExpireDue(ctx context.Context, today time.Time) ([]uuid.UUID, error) {
+	query := `UPDATE cards SET status = 'expired', updated_at = NOW()
+		WHERE status = 'active' AND expiry_date < $1
+		RETURNING id`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, today)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire cards: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// Extend sets the new expiry date and status of a card that is not blocked.
+// The status is checked in the update itself, so a card blocked after it was
+// read is not reactivated.
+func (r *cardRepository) Extend(ctx context.Context, card *model.Card) error {
+	query := `UPDATE cards SET expiry_date = $2, status = $3::card_status, updated_at = $4
+		WHERE id = $1 AND replaced_by IS NULL AND status <> 'blocked'`
+
+	result, err := conn(ctx, r.db).Exec(ctx, query, card.ID, card.ExpiryDate, card.Status, card.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to extend card: %w", mapWriteError(err))
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("card with id %s: %w", card.ID, ErrCardBlocked)
+	}
+
+	return nil
+}
+
+// Replace issues card as the successor of oldID in one transaction: the
+// remaining balance moves to the new card through the ledger and the old card
+// is blocked and linked to it. A blocked card is only replaced when
+// allowBlocked is set; its status is checked under the row lock.
+func (r *cardRepository) Replace(ctx context.Context, oldID uuid.UUID, card *model.Card, reason string, allowBlocked bool) ([]model.LedgerEntry, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	locked, err := lockCards(ctx, tx, oldID)
+	if err != nil {
+		return nil, err
+	}
+
+	old := locked[oldID]
+	if old.ReplacedBy != nil {
+		return nil, ErrCardUnavailable
+	}
+	if old.Status == "blocked" && !allowBlocked {
+		return nil, ErrCardBlocked
+	}
+
+	if err := checkNoOpenTrip(ctx, tx, oldID); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO cards (id, card_number, balance, status, issued_date, expiry_date, holder_id, created_at, updated_at)
+		VALUES ($1, $2, 0, $3::card_status, $4, $5, $6, $7, $8)`
+
+	_, err = tx.Exec(ctx, query,
+		card.ID, card.CardNumber, card.Status, card.IssuedDate, card.ExpiryDate, card.HolderID, card.CreatedAt, card.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create card: %w", mapWriteError(err))
+	}
+
+	entries, err := transferBalance(ctx, tx, old, lockedCard{ID: card.ID}, reason, card.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `UPDATE cards SET status = 'blocked', replaced_by = $2, updated_at = $3 WHERE id = $1`
+
+	if _, err := tx.Exec(ctx, query, oldID, card.ID, card.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to update card: %w", mapWriteError(err))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	card.Balance = old.Balance
+
+	return entries, nil
+}
+
+// Transfer blocks sourceID and moves its whole balance to targetID. Both rows
+// stay locked until commit, so neither card can be charged or topped up in
+// between.
+func (r *cardRepository) Transfer(ctx context.Context, sourceID, targetID uuid.UUID, reason string, at time.Time) ([]model.LedgerEntry, error) {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	locked, err := lockCards(ctx, tx, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	source, target := locked[sourceID], locked[targetID]
+	if source.ReplacedBy != nil || target.ReplacedBy != nil || target.Status != "active" {
+		return nil, ErrCardUnavailable
+	}
+
+	if err := checkNoOpenTrip(ctx, tx, sourceID); err != nil {
+		return nil, err
+	}
+
+	entries, err := transferBalance(ctx, tx, source, target, reason, at)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE cards SET status = 'blocked', updated_at = $2 WHERE id = $1`, sourceID, at); err != nil {
+		return nil, fmt.Errorf("failed to update card: %w", mapWriteError(err))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return entries, nil
+}
+
+type lockedCard struct {
+	ID         uuid.UUID
+	Balance    money.Amount
+	Status     string
+	ReplacedBy *uuid.UUID
+}
+
+// lockCards locks the given cards FOR UPDATE in ID order, so two transfers
+// touching the same pair cannot deadlock.
+func lockCards(ctx context.Context, tx pgx.Tx, ids ...uuid.UUID) (map[uuid.UUID]lockedCard, error) {
+	query := `SELECT id, balance, status::text, replaced_by FROM cards WHERE id = ANY($1) ORDER BY id FOR UPDATE`
+
+	rows, err := tx.Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock cards: %w", err)
+	}
+	defer rows.Close()
+
+	locked := make(map[uuid.UUID]lockedCard, len(ids))
+	for rows.Next() {
+		var card lockedCard
+		if err := rows.Scan(&card.ID, &card.Balance, &card.Status, &card.ReplacedBy); err != nil {
+			return nil, fmt.Errorf("failed to scan card: %w", err)
+		}
+		locked[card.ID] = card
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to lock cards: %w", err)
+	}
+
+	for _, id := range ids {
+		if _, ok := locked[id]; !ok {
+			return nil, fmt.Errorf("card with id %s: %w", id, ErrNotFound)
+		}
+	}
+
+	return locked, nil
+}
+
+// checkNoOpenTrip fails when the last tap of the card is a tap-in, since the
+// fare of that trip has not been charged yet.
+func checkNoOpenTrip(ctx context.Context, tx pgx.Tx, cardID uuid.UUID) error {
+	query := `SELECT transaction_type::text FROM transactions WHERE card_id = $1 ORDER BY transaction_time DESC, id DESC LIMIT 1`
+
+	var last string
+	err := tx.QueryRow(ctx, query, cardID).Scan(&last)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get last transaction: %w", err)
+	}
+
+	if last == "tap_in" {
+		return ErrOpenTrip
+	}
+
+	return nil
+}
+
+// transferBalance moves the whole balance of from to to and writes a debit
+// and a credit entry that reference each other. Both cards must already be
+// locked by tx. Nothing is written for an empty balance.
+func transferBalance(ctx context.Context, tx pgx.Tx, from, to lockedCard, reason string, at time.Time) ([]model.LedgerEntry, error) {
+	if from.Balance <= 0 {
+		return []model.LedgerEntry{}, nil
+	}
+
+	debit := model.LedgerEntry{
+		CardID:       from.ID,
+		EntryType:    "debit",
+		Amount:       from.Balance,
+		BalanceAfter: 0,
+		Reason:       reason,
+		CreatedAt:    at,
+	}
+	credit := model.LedgerEntry{
+		CardID:       to.ID,
+		EntryType:    "credit",
+		Amount:       from.Balance,
+		BalanceAfter: to.Balance + from.Balance,
+		Reason:       reason,
+		CreatedAt:    at,
+	}
+
+	query := `INSERT INTO card_ledger (card_id, entry_type, amount, balance_after, reason, counterpart_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	err := tx.QueryRow(ctx, query, debit.CardID, debit.EntryType, debit.Amount, debit.BalanceAfter, debit.Reason, nil, debit.CreatedAt).Scan(&debit.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert ledger entry: %w", mapWriteError(err))
+	}
+
+	credit.CounterpartID = &debit.ID
+	err = tx.QueryRow(ctx, query, credit.CardID, credit.EntryType, credit.Amount, credit.BalanceAfter, credit.Reason, credit.CounterpartID, credit.CreatedAt).Scan(&credit.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert ledger entry: %w", mapWriteError(err))
+	}
+
+	debit.CounterpartID = &credit.ID
+	if _, err := tx.Exec(ctx, `UPDATE card_ledger SET counterpart_id = $2 WHERE id = $1`, debit.ID, credit.ID); err != nil {
+		return nil, fmt.Errorf("failed to link ledger entries: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE cards SET balance = 0, updated_at = $2 WHERE id = $1`, from.ID, at); err != nil {
+		return nil, fmt.Errorf("failed to debit card: %w", mapWriteError(err))
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE cards SET balance = $2, updated_at = $3 WHERE id = $1`, to.ID, credit.BalanceAfter, at); err != nil {
+		return nil, fmt.Errorf("failed to credit card: %w", mapWriteError(err))
+	}
+
+	return []model.LedgerEntry{debit, credit}, nil
+}
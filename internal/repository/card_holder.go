@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CardHolderRepository interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*model.CardHolder, error)
+	FindByCard(ctx context.Context, cardID uuid.UUID) (*model.CardHolder, error)
+	Lookup(ctx context.Context, phone, email, idNumberHash *string) ([]model.CardHolder, error)
+	ListCards(ctx context.Context, holderID uuid.UUID) ([]model.Card, error)
+	Register(ctx context.Context, cardID uuid.UUID, holder *model.CardHolder) error
+	Update(ctx context.Context, holder *model.CardHolder) (*model.CardHolder, error)
+	Unregister(ctx context.Context, cardID uuid.UUID, updatedAt time.Time) error
+}
+
+// lookupLimit caps holder lookups, which are meant to find one person rather
+// than browse holders.
+const lookupLimit = 50
+
+var (
+	ErrCardHasHolder = apperror.Conflict("card is already registered to another holder")
+	ErrCardNoHolder  = apperror.NotFound("card has no registered holder")
+)
+
+type cardHolderRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCardHolderRepository(db *pgxpool.Pool) CardHolderRepository {
+	return &cardHolderRepository{db: db}
+}
+
+func (r *cardHolderRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.CardHolder, error) {
+	query := `SELECT id, name, phone, email, id_number_hash, created_at, updated_at FROM card_holders WHERE id = $1`
+
+	var holder model.CardHolder
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
+		&holder.ID, &holder.Name, &holder.Phone, &holder.Email,
+		&holder.IDNumberHash, &holder.CreatedAt, &holder.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get card holder: %w", mapError(err))
+	}
+
+	return &holder, nil
+}
+
+func (r *cardHolderRepository) FindByCard(ctx context.Context, cardID uuid.UUID) (*model.CardHolder, error) {
+	var holderID *uuid.UUID
+	err := conn(ctx, r.db).QueryRow(ctx, `SELECT holder_id FROM cards WHERE id = $1`, cardID).Scan(&holderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get card: %w", mapError(err))
+	}
+
+	if holderID == nil {
+		return nil, ErrCardNoHolder
+	}
+
+	return r.FindByID(ctx, *holderID)
+}
+
+// Lookup returns the holders matching every given criterion. Emails are
+// compared case-insensitively.
+func (r *cardHolderRepository) Lookup(ctx context.Context, phone, email, idNumberHash *string) ([]model.CardHolder, error) {
+	var q listQuery
+
+	if phone != nil {
+		q.where("phone = $%d", *phone)
+	}
+	if email != nil {
+		q.where("LOWER(email) = LOWER($%d)", *email)
+	}
+	if idNumberHash != nil {
+		q.where("id_number_hash = $%d", *idNumberHash)
+	}
+	if len(q.conditions) == 0 {
+		return []model.CardHolder{}, nil
+	}
+
+	q.orderBy = "name, id"
+	query, args := q.page(`SELECT id, name, phone, email, id_number_hash, created_at, updated_at FROM card_holders`, lookupLimit, 0)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query card holders: %w", err)
+	}
+	defer rows.Close()
+
+	holders, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.CardHolder])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return holders, nil
+}
+
+func (r *cardHolderRepository) ListCards(ctx context.Context, holderID uuid.UUID) ([]model.Card, error) {
+	query := `SELECT id, card_number, balance, status::text AS status, issued_date, expiry_date, replaced_by, holder_id, created_at, updated_at
+		FROM cards
+		WHERE holder_id = $1
+		ORDER BY issued_date, card_number`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, holderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cards: %w", err)
+	}
+	defer rows.Close()
+
+	cards, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Card])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return cards, nil
+}
+
+// Register links the card to the holder with the same identity number hash,
+// creating the holder if there is none. An existing holder is linked as is:
+// its contact details only change through Update. holder is overwritten with
+// the stored values.
+func (r *cardHolderRepository) Register(ctx context.Context, cardID uuid.UUID, holder *model.CardHolder) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var current *uuid.UUID
+	err = tx.QueryRow(ctx, `SELECT holder_id FROM cards WHERE id = $1 FOR UPDATE`, cardID).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("card with id %s: %w", cardID, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get card: %w", err)
+	}
+
+	linkedAt := holder.UpdatedAt
+
+	// The no-op update returns and locks the existing row, which DO NOTHING
+	// would not, so a concurrent Unregister cannot delete the holder before
+	// the card is linked to it.
+	query := `INSERT INTO card_holders (id, name, phone, email, id_number_hash, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id_number_hash) DO UPDATE SET id_number_hash = EXCLUDED.id_number_hash
+		RETURNING id, name, phone, email, created_at, updated_at`
+
+	err = tx.QueryRow(ctx, query,
+		holder.ID, holder.Name, holder.Phone, holder.Email, holder.IDNumberHash, holder.CreatedAt, holder.UpdatedAt,
+	).Scan(&holder.ID, &holder.Name, &holder.Phone, &holder.Email, &holder.CreatedAt, &holder.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert card holder: %w", mapWriteError(err))
+	}
+
+	if current != nil && *current != holder.ID {
+		return ErrCardHasHolder
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE cards SET holder_id = $2, updated_at = $3 WHERE id = $1`, cardID, holder.ID, linkedAt); err != nil {
+		return fmt.Errorf("failed to update card: %w", mapWriteError(err))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces the holder's contact details and returns the values they
+// replaced, read under the same row lock. holder.IDNumberHash and
+// holder.CreatedAt are set to the stored values.
+func (r *cardHolderRepository) Update(ctx context.Context, holder *model.CardHolder) (*model.CardHolder, error) {
+	query := `WITH old AS (
+			SELECT id, name, phone, email, updated_at FROM card_holders WHERE id = $1 FOR UPDATE
+		)
+		UPDATE card_holders h SET name = $2, phone = $3, email = $4, updated_at = $5
+		FROM old
+		WHERE h.id = old.id
+		RETURNING h.id_number_hash, h.created_at, old.name, old.phone, old.email, old.updated_at`
+
+	before := model.CardHolder{ID: holder.ID}
+	err := conn(ctx, r.db).QueryRow(ctx, query, holder.ID, holder.Name, holder.Phone, holder.Email, holder.UpdatedAt).Scan(
+		&holder.IDNumberHash, &holder.CreatedAt, &before.Name, &before.Phone, &before.Email, &before.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("card holder with id %s: %w", holder.ID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update card holder: %w", mapWriteError(err))
+	}
+
+	before.IDNumberHash = holder.IDNumberHash
+	before.CreatedAt = holder.CreatedAt
+
+	return &before, nil
+}
+
+// Unregister makes the card anonymous again. A holder left without cards is
+// deleted so no personal data outlives its last card.
+func (r *cardHolderRepository) Unregister(ctx context.Context, cardID uuid.UUID, updatedAt time.Time) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var holderID *uuid.UUID
+	err = tx.QueryRow(ctx, `SELECT holder_id FROM cards WHERE id = $1 FOR UPDATE`, cardID).Scan(&holderID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("card with id %s: %w", cardID, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get card: %w", err)
+	}
+
+	if holderID == nil {
+		return ErrCardNoHolder
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE cards SET holder_id = NULL, updated_at = $2 WHERE id = $1`, cardID, updatedAt); err != nil {
+		return fmt.Errorf("failed to update card: %w", mapWriteError(err))
+	}
+
+	query := `DELETE FROM card_holders WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM cards WHERE holder_id = $1)`
+
+	if _, err := tx.Exec(ctx, query, *holderID); err != nil {
+		return fmt.Errorf("failed to delete card holder: %w", mapError(err))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
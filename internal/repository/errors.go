@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+)
+
+var (
+	ErrNotFound     = apperror.NotFound("resource not found")
+	ErrConflict     = apperror.Conflict("resource already exists")
+	ErrReferenced   = apperror.Conflict("resource is still referenced by other records")
+	ErrBadReference = apperror.BadRequest("referenced resource does not exist")
+	ErrStale        = apperror.PreconditionFailed("resource was modified by another request")
+)
+
+// mapError translates driver errors into the domain sentinels above so that
+// callers can match them with errors.Is. Other errors are returned unchanged.
+// It is meant for reads and deletes, where a foreign key violation means the
+// row is still referenced by another table.
+func mapError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound.Wrap(err)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return ErrConflict.Wrap(err)
+		case pgForeignKeyViolation:
+			return ErrReferenced.Wrap(err)
+		}
+	}
+
+	return err
+}
+
+// mapWriteError is mapError for inserts and updates. Postgres reports both
+// directions of a foreign key violation with the same code, so the kind of
+// statement tells them apart: a write fails when the row points at a record
+// that does not exist.
+func mapWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+		return ErrBadReference.Wrap(err)
+	}
+
+	return mapError(err)
+}
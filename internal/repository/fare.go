@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type FareRepository interface {
+	List(ctx context.Context) ([]model.FareMatrix, error)
+	Find(ctx context.Context, originID, destinationID uuid.UUID) (*model.FareMatrix, error)
+	Upsert(ctx context.Context, fares []model.FareMatrix) error
+	LockForGeneration(ctx context.Context) error
+}
+
+type fareRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFareRepository(db *pgxpool.Pool) FareRepository {
+	return &fareRepository{db: db}
+}
+
+func (r *fareRepository) List(ctx context.Context) ([]model.FareMatrix, error) {
+	query := `SELECT origin_terminal_id, destination_terminal_id, fare_amount, is_active, created_at, updated_at FROM fare_matrix`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fare matrix: %w", err)
+	}
+	defer rows.Close()
+
+	fares, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.FareMatrix])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return fares, nil
+}
+
+func (r *fareRepository) Find(ctx context.Context, originID, destinationID uuid.UUID) (*model.FareMatrix, error) {
+	query := `SELECT origin_terminal_id, destination_terminal_id, fare_amount, is_active, created_at, updated_at FROM fare_matrix WHERE origin_terminal_id = $1 AND destination_terminal_id = $2`
+
+	var fare model.FareMatrix
+	err := conn(ctx, r.db).QueryRow(ctx, query, originID, destinationID).Scan(
+		&fare.OriginTerminalID, &fare.DestinationTerminalID, &fare.FareAmount,
+		&fare.IsActive, &fare.CreatedAt, &fare.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fare: %w", mapError(err))
+	}
+
+	return &fare, nil
+}
+
+// Upsert writes all fares in a single transaction, so either the whole
+// generated matrix is applied or none of it is.
+func (r *fareRepository) Upsert(ctx context.Context, fares []model.FareMatrix) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO fare_matrix (origin_terminal_id, destination_terminal_id, fare_amount, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (origin_terminal_id, destination_terminal_id)
+		DO UPDATE SET fare_amount = EXCLUDED.fare_amount, is_active = EXCLUDED.is_active, updated_at = EXCLUDED.updated_at`
+
+	for _, fare := range fares {
+		_, err := tx.Exec(ctx, query,
+			fare.OriginTerminalID, fare.DestinationTerminalID, fare.FareAmount, fare.IsActive, fare.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to upsert fare: %w", mapWriteError(err))
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// LockForGeneration blocks writes to the fare matrix and route distances until
+// the transaction carried by ctx ends, so fares generated inside it are
+// computed from the data they are applied to.
+func (r *fareRepository) LockForGeneration(ctx context.Context) error {
+	query := `LOCK TABLE fare_matrix, route_distances IN SHARE ROW EXCLUSIVE MODE`
+
+	if _, err := conn(ctx, r.db).Exec(ctx, query); err != nil {
+		return fmt.Errorf("failed to lock fare matrix: %w", err)
+	}
+
+	return nil
+}
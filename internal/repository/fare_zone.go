@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type FareZoneRepository interface {
+	ListZones(ctx context.Context) ([]model.FareZone, error)
+	UpsertZone(ctx context.Context, zone *model.FareZone) error
+	ListZoneFares(ctx context.Context) ([]model.ZoneFare, error)
+	FindZoneFare(ctx context.Context, zonesTravelled int) (*model.ZoneFare, error)
+	UpsertZoneFare(ctx context.Context, fare *model.ZoneFare) error
+}
+
+type fareZoneRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFareZoneRepository(db *pgxpool.Pool) FareZoneRepository {
+	return &fareZoneRepository{db: db}
+}
+
+func (r *fareZoneRepository) ListZones(ctx context.Context) ([]model.FareZone, error) {
+	query := `SELECT zone, name, created_at, updated_at FROM fare_zones ORDER BY zone`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fare zones: %w", err)
+	}
+	defer rows.Close()
+
+	zones, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.FareZone])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return zones, nil
+}
+
+func (r *fareZoneRepository) UpsertZone(ctx context.Context, zone *model.FareZone) error {
+	query := `INSERT INTO fare_zones (zone, name, created_at, updated_at) VALUES ($1, $2, $3, $3)
+		ON CONFLICT (zone) DO UPDATE SET name = EXCLUDED.name, updated_at = EXCLUDED.updated_at
+		RETURNING created_at`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, zone.Zone, zone.Name, zone.UpdatedAt).Scan(&zone.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert fare zone: %w", mapWriteError(err))
+	}
+
+	return nil
+}
+
+func (r *fareZoneRepository) ListZoneFares(ctx context.Context) ([]model.ZoneFare, error) {
+	query := `SELECT zones_travelled, fare_amount, created_at, updated_at FROM zone_fares ORDER BY zones_travelled`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query zone fares: %w", err)
+	}
+	defer rows.Close()
+
+	fares, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.ZoneFare])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return fares, nil
+}
+
+func (r *fareZoneRepository) FindZoneFare(ctx context.Context, zonesTravelled int) (*model.ZoneFare, error) {
+	query := `SELECT zones_travelled, fare_amount, created_at, updated_at FROM zone_fares WHERE zones_travelled = $1`
+
+	var fare model.ZoneFare
+	err := conn(ctx, r.db).QueryRow(ctx, query, zonesTravelled).Scan(
+		&fare.ZonesTravelled, &fare.FareAmount, &fare.CreatedAt, &fare.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get zone fare: %w", mapError(err))
+	}
+
+	return &fare, nil
+}
+
+func (r *fareZoneRepository) UpsertZoneFare(ctx context.Context, fare *model.ZoneFare) error {
+	query := `INSERT INTO zone_fares (zones_travelled, fare_amount, created_at, updated_at) VALUES ($1, $2, $3, $3)
+		ON CONFLICT (zones_travelled) DO UPDATE SET fare_amount = EXCLUDED.fare_amount, updated_at = EXCLUDED.updated_at
+		RETURNING created_at`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, fare.ZonesTravelled, fare.FareAmount, fare.UpdatedAt).Scan(&fare.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert zone fare: %w", mapWriteError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type GateRepository interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Gate, error)
+	FindByKeyHash(ctx context.Context, keyHash string) (*model.Gate, error)
+	SetKeyHash(ctx context.Context, id uuid.UUID, keyHash string, issuedAt time.Time) error
+	CreateTapFailure(ctx context.Context, failure *model.TapFailure) error
+}
+
+type gateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewGateRepository(db *pgxpool.Pool) GateRepository {
+	return &gateRepository{db: db}
+}
+
+func (r *gateRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Gate, error) {
+	query := `SELECT id, code, name, terminal_id, is_active, created_at, updated_at FROM gates WHERE id = $1`
+
+	gate, err := r.scan(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("gate with id %s: %w", id, err)
+	}
+
+	return gate, nil
+}
+
+func (r *gateRepository) FindByKeyHash(ctx context.Context, keyHash string) (*model.Gate, error) {
+	query := `SELECT id, code, name, terminal_id, is_active, created_at, updated_at FROM gates WHERE key_hash = $1`
+
+	gate, err := r.scan(ctx, query, keyHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gate by key: %w", err)
+	}
+
+	return gate, nil
+}
+
+func (r *gateRepository) SetKeyHash(ctx context.Context, id uuid.UUID, keyHash string, issuedAt time.Time) error {
+	query := `UPDATE gates SET key_hash = $2, key_issued_at = $3, updated_at = $3 WHERE id = $1`
+
+	result, err := conn(ctx, r.db).Exec(ctx, query, id, keyHash, issuedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set gate key: %w", mapWriteError(err))
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("gate with id %s: %w", id, ErrNotFound)
+	}
+
+	return nil
+}
+
+// CreateTapFailure stores a rejected tap, linking it to the card with the
+// reported number when there is one.
+func (r *gateRepository) CreateTapFailure(ctx context.Context, failure *model.TapFailure) error {
+	query := `INSERT INTO tap_failures (card_id, card_number, gate_id, terminal_id, tap_type, reason, occurred_at)
+		VALUES ((SELECT id FROM cards WHERE card_number = $1), $1, $2, $3, $4, $5, $6)
+		RETURNING id, card_id`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		failure.CardNumber, failure.GateID, failure.TerminalID, failure.TapType, failure.Reason, failure.OccurredAt,
+	).Scan(&failure.ID, &failure.CardID)
+	if err != nil {
+		return fmt.Errorf("failed to create tap failure: %w", mapWriteError(err))
+	}
+
+	return nil
+}
+
+func (r *gateRepository) scan(ctx context.Context, query string, arg any) (*model.Gate, error) {
+	var gate model.Gate
+	err := conn(ctx, r.db).QueryRow(ctx, query, arg).Scan(
+		&gate.ID, &gate.Code, &gate.Name, &gate.TerminalID, &gate.IsActive, &gate.CreatedAt, &gate.UpdatedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &gate, nil
+}
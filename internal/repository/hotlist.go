@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type HotlistRepository interface {
+	ListSince(ctx context.Context, since int64, limit int) ([]model.HotlistEntry, error)
+	Snapshot(ctx context.Context, status string) (int64, []string, error)
+	Version(ctx context.Context) (int64, error)
+}
+
+type hotlistRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewHotlistRepository(db *pgxpool.Pool) HotlistRepository {
+	return &hotlistRepository{db: db}
+}
+
+func (r *hotlistRepository) ListSince(ctx context.Context, since int64, limit int) ([]model.HotlistEntry, error) {
+	query := `SELECT version, card_id, card_number, status::text AS status, changed_at
+		FROM card_hotlist
+		WHERE version > $1
+		ORDER BY version
+		LIMIT $2`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query hotlist: %w", err)
+	}
+	defer rows.Close()
+
+	entries, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.HotlistEntry])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Version returns the latest hotlist version, 0 when the hotlist is empty.
+func (r *hotlistRepository) Version(ctx context.Context) (int64, error) {
+	var version int64
+	if err := conn(ctx, r.db).QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM card_hotlist`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to get hotlist version: %w", err)
+	}
+
+	return version, nil
+}
+
+// Snapshot returns the card numbers currently in the given status together
+// with the hotlist version they correspond to, read from a single snapshot
+// so a gate can continue with deltas from exactly that version.
+func (r *hotlistRepository) Snapshot(ctx context.Context, status string) (int64, []string, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var version int64
+	if err := tx.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM card_hotlist`).Scan(&version); err != nil {
+		return 0, nil, fmt.Errorf("failed to get hotlist version: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `SELECT card_number FROM cards WHERE status = $1::card_status ORDER BY card_number`, status)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to query cards: %w", err)
+	}
+
+	numbers, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return version, numbers, nil
+}
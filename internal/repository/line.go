@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LineRepository interface {
+	List(ctx context.Context) ([]model.Line, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Line, error)
+	ListStops(ctx context.Context, lineID uuid.UUID) ([]model.LineStop, error)
+	ListByTerminal(ctx context.Context, terminalID uuid.UUID) ([]model.LineSummary, error)
+	Create(ctx context.Context, line *model.Line, terminalIDs []uuid.UUID) error
+	Update(ctx context.Context, line *model.Line) error
+	ReplaceStops(ctx context.Context, lineID uuid.UUID, terminalIDs []uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type lineRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLineRepository(db *pgxpool.Pool) LineRepository {
+	return &lineRepository{db: db}
+}
+
+func (r *lineRepository) List(ctx context.Context) ([]model.Line, error) {
+	query := `SELECT id, code, name, color, is_active, created_at, updated_at FROM lines ORDER BY code`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lines: %w", err)
+	}
+	defer rows.Close()
+
+	lines, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Line])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return lines, nil
+}
+
+func (r *lineRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Line, error) {
+	query := `SELECT id, code, name, color, is_active, created_at, updated_at FROM lines WHERE id = $1`
+
+	var line model.Line
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
+		&line.ID, &line.Code, &line.Name, &line.Color,
+		&line.IsActive, &line.CreatedAt, &line.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get line: %w", mapError(err))
+	}
+
+	return &line, nil
+}
+
+func (r *lineRepository) ListStops(ctx context.Context, lineID uuid.UUID) ([]model.LineStop, error) {
+	query := `SELECT s.sequence, s.terminal_id, t.code AS terminal_code, t.name AS terminal_name
+		FROM line_stops s
+		JOIN terminals t ON t.id = s.terminal_id
+		WHERE s.line_id = $1
+		ORDER BY s.sequence`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, lineID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query line stops: %w", err)
+	}
+	defer rows.Close()
+
+	stops, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.LineStop])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return stops, nil
+}
+
+func (r *lineRepository) ListByTerminal(ctx context.Context, terminalID uuid.UUID) ([]model.LineSummary, error) {
+	query := `SELECT l.id, l.code, l.name, l.color, s.sequence
+		FROM line_stops s
+		JOIN lines l ON l.id = s.line_id
+		WHERE s.terminal_id = $1 AND l.is_active
+		ORDER BY l.code`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, terminalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query terminal lines: %w", err)
+	}
+	defer rows.Close()
+
+	lines, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.LineSummary])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return lines, nil
+}
+
+func (r *lineRepository) Create(ctx context.Context, line *model.Line, terminalIDs []uuid.UUID) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO lines (id, code, name, color, is_active, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = tx.Exec(ctx, query,
+		line.ID, line.Code, line.Name, line.Color,
+		line.IsActive, line.CreatedAt, line.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create line: %w", mapWriteError(err))
+	}
+
+	if err := insertLineStops(ctx, tx, line.ID, terminalIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *lineRepository) Update(ctx context.Context, line *model.Line) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE lines SET name = $2, color = $3, is_active = $4, updated_at = $5 WHERE id = $1`
+
+	result, err := tx.Exec(ctx, query, line.ID, line.Name, line.Color, line.IsActive, line.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update line: %w", mapWriteError(err))
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("line with id %s: %w", line.ID, ErrNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReplaceStops swaps the whole ordered stop list of a line in one
+// transaction, so readers never observe a partially reordered line.
+func (r *lineRepository) ReplaceStops(ctx context.Context, lineID uuid.UUID, terminalIDs []uuid.UUID) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM line_stops WHERE line_id = $1`, lineID); err != nil {
+		return fmt.Errorf("failed to delete line stops: %w", err)
+	}
+
+	if err := insertLineStops(ctx, tx, lineID, terminalIDs); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE lines SET updated_at = NOW() WHERE id = $1`, lineID); err != nil {
+		return fmt.Errorf("failed to update line: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *lineRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `DELETE FROM lines WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete line: %w", mapError(err))
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("line with id %s: %w", id, ErrNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// insertLineStops only adds terminals that are not deleted. The row lock
+// serialises it with SoftDelete, so a stop is never added to a terminal that
+// is being deleted.
+func insertLineStops(ctx context.Context, tx pgx.Tx, lineID uuid.UUID, terminalIDs []uuid.UUID) error {
+	query := `INSERT INTO line_stops (line_id, terminal_id, sequence)
+		SELECT $1, id, $3 FROM terminals WHERE id = $2 AND deleted_at IS NULL FOR SHARE`
+
+	for i, terminalID := range terminalIDs {
+		result, err := tx.Exec(ctx, query, lineID, terminalID, i+1)
+		if err != nil {
+			return fmt.Errorf("failed to create line stop: %w", mapWriteError(err))
+		}
+		if result.RowsAffected() == 0 {
+			return fmt.Errorf("terminal with id %s: %w", terminalID, ErrBadReference)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LoginAttemptRepository interface {
+	Record(ctx context.Context, username, ip string) error
+	CountByUsername(ctx context.Context, username string, window time.Duration) (int, error)
+	CountByIP(ctx context.Context, ip string, window time.Duration) (int, error)
+	ClearByUsername(ctx context.Context, username string) error
+}
+
+type loginAttemptRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLoginAttemptRepository(db *pgxpool.Pool) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+func (r *loginAttemptRepository) Record(ctx context.Context, username, ip string) error {
+	query := `INSERT INTO login_attempts (username, ip_address) VALUES ($1, $2)`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query, username, ip)
+	if err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	return nil
+}
+
+// CountByUsername counts the attempts within window of now. The window is
+// computed by the database, which also stamps attempted_at, so the count does
+// not depend on the application clock or time zone.
+func (r *loginAttemptRepository) CountByUsername(ctx context.Context, username string, window time.Duration) (int, error) {
+	query := `SELECT COUNT(*) FROM login_attempts WHERE username = $1 AND attempted_at >= NOW() - $2 * INTERVAL '1 second'`
+
+	var count int
+	if err := conn(ctx, r.db).QueryRow(ctx, query, username, window.Seconds()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count login attempts: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *loginAttemptRepository) CountByIP(ctx context.Context, ip string, window time.Duration) (int, error) {
+	query := `SELECT COUNT(*) FROM login_attempts WHERE ip_address = $1 AND attempted_at >= NOW() - $2 * INTERVAL '1 second'`
+
+	var count int
+	if err := conn(ctx, r.db).QueryRow(ctx, query, ip, window.Seconds()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count login attempts: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *loginAttemptRepository) ClearByUsername(ctx context.Context, username string) error {
+	query := `DELETE FROM login_attempts WHERE username = $1`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query, username)
+	if err != nil {
+		return fmt.Errorf("failed to clear login attempts: %w", err)
+	}
+
+	return nil
+}
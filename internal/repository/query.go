@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// listQuery accumulates WHERE conditions and their positional arguments for
+// list endpoints, so filters, sorting and pagination are built the same way
+// for every table.
+type listQuery struct {
+	conditions []string
+	args       []any
+	orderBy    string
+}
+
+// where adds a condition bound to value. The condition refers to the
+// argument with a single %d verb, or %[1]d when it is used more than once.
+func (q *listQuery) where(condition string, value any) {
+	q.args = append(q.args, value)
+	q.conditions = append(q.conditions, fmt.Sprintf(condition, len(q.args)))
+}
+
+// whereRaw adds a condition that takes no arguments.
+func (q *listQuery) whereRaw(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+// search matches term case-insensitively as a substring of any of columns.
+func (q *listQuery) search(term string, columns ...string) {
+	if term == "" || len(columns) == 0 {
+		return
+	}
+
+	matches := make([]string, len(columns))
+	for i, column := range columns {
+		matches[i] = column + ` ILIKE $%[1]d ESCAPE '\'`
+	}
+
+	q.where("("+strings.Join(matches, " OR ")+")", "%"+escapeLike(term)+"%")
+}
+
+// sort orders by the column mapped from field, falling back to fallback when
+// field is not in columns. tiebreaker keeps pagination stable between pages.
+func (q *listQuery) sort(field, direction string, columns map[string]string, fallback, tiebreaker string) {
+	column, ok := columns[field]
+	if !ok {
+		column = fallback
+	}
+
+	if strings.EqualFold(direction, "desc") {
+		direction = "DESC"
+	} else {
+		direction = "ASC"
+	}
+
+	q.orderBy = column + " " + direction
+	if tiebreaker != "" && tiebreaker != column {
+		q.orderBy += ", " + tiebreaker + " " + direction
+	}
+}
+
+func (q *listQuery) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+// count returns the query counting every row matching the conditions.
+func (q *listQuery) count(table string) (string, []any) {
+	return "SELECT COUNT(*) FROM " + table + q.whereClause(), q.args
+}
+
+// page returns the query selecting one page of rows matching the conditions.
+func (q *listQuery) page(selectFrom string, limit, offset int) (string, []any) {
+	query := selectFrom + q.whereClause()
+	if q.orderBy != "" {
+		query += " ORDER BY " + q.orderBy
+	}
+
+	args := append(q.args[:len(q.args):len(q.args)], limit, offset)
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	return query, args
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
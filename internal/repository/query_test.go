@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestListQuery(t *testing.T) {
+	columns := map[string]string{"name": "name", "code": "code", "created_at": "created_at"}
+
+	tests := []struct {
+		name      string
+		build     func(q *listQuery)
+		wantPage  string
+		wantCount string
+		wantArgs  []any
+	}{
+		{
+			name:      "no conditions",
+			build:     func(q *listQuery) {},
+			wantPage:  "SELECT * FROM t LIMIT $1 OFFSET $2",
+			wantCount: "SELECT COUNT(*) FROM t",
+			wantArgs:  []any{},
+		},
+		{
+			name: "conditions are numbered in order",
+			build: func(q *listQuery) {
+				q.where("is_active = $%d", true)
+				q.whereRaw("deleted_at IS NULL")
+				q.where("fare_zone = $%d", 2)
+			},
+			wantPage:  "SELECT * FROM t WHERE is_active = $1 AND deleted_at IS NULL AND fare_zone = $2 LIMIT $3 OFFSET $4",
+			wantCount: "SELECT COUNT(*) FROM t WHERE is_active = $1 AND deleted_at IS NULL AND fare_zone = $2",
+			wantArgs:  []any{true, 2},
+		},
+		{
+			name: "search binds one escaped argument",
+			build: func(q *listQuery) {
+				q.search(`50%_off\`, "name", "code")
+			},
+			wantPage:  `SELECT * FROM t WHERE (name ILIKE $1 ESCAPE '\' OR code ILIKE $1 ESCAPE '\') LIMIT $2 OFFSET $3`,
+			wantCount: `SELECT COUNT(*) FROM t WHERE (name ILIKE $1 ESCAPE '\' OR code ILIKE $1 ESCAPE '\')`,
+			wantArgs:  []any{`%50\%\_off\\%`},
+		},
+		{
+			name: "empty search is ignored",
+			build: func(q *listQuery) {
+				q.search("", "name")
+			},
+			wantPage:  "SELECT * FROM t LIMIT $1 OFFSET $2",
+			wantCount: "SELECT COUNT(*) FROM t",
+			wantArgs:  []any{},
+		},
+		{
+			name: "sort with tiebreaker",
+			build: func(q *listQuery) {
+				q.sort("name", "DESC", columns, "created_at", "id")
+			},
+			wantPage:  "SELECT * FROM t ORDER BY name DESC, id DESC LIMIT $1 OFFSET $2",
+			wantCount: "SELECT COUNT(*) FROM t",
+			wantArgs:  []any{},
+		},
+		{
+			name: "unknown sort field falls back",
+			build: func(q *listQuery) {
+				q.sort("password; DROP TABLE t", "sideways", columns, "created_at", "id")
+			},
+			wantPage:  "SELECT * FROM t ORDER BY created_at ASC, id ASC LIMIT $1 OFFSET $2",
+			wantCount: "SELECT COUNT(*) FROM t",
+			wantArgs:  []any{},
+		},
+		{
+			name: "tiebreaker is not repeated",
+			build: func(q *listQuery) {
+				q.sort("id", "asc", map[string]string{"id": "id"}, "id", "id")
+			},
+			wantPage:  "SELECT * FROM t ORDER BY id ASC LIMIT $1 OFFSET $2",
+			wantCount: "SELECT COUNT(*) FROM t",
+			wantArgs:  []any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q listQuery
+			tt.build(&q)
+
+			count, countArgs := q.count("t")
+			if count != tt.wantCount {
+				t.Errorf("count() query = %q, want %q", count, tt.wantCount)
+			}
+			if !sameArgs(countArgs, tt.wantArgs) {
+				t.Errorf("count() args = %v, want %v", countArgs, tt.wantArgs)
+			}
+
+			page, pageArgs := q.page("SELECT * FROM t", 20, 40)
+			if page != tt.wantPage {
+				t.Errorf("page() query = %q, want %q", page, tt.wantPage)
+			}
+			wantPageArgs := append(append([]any{}, tt.wantArgs...), 20, 40)
+			if !sameArgs(pageArgs, wantPageArgs) {
+				t.Errorf("page() args = %v, want %v", pageArgs, wantPageArgs)
+			}
+		})
+	}
+}
+
+// TestListQueryPageKeepsArgs checks that building a page does not leave the
+// limit and offset behind in the arguments shared with count.
+func TestListQueryPageKeepsArgs(t *testing.T) {
+	var q listQuery
+	q.where("a = $%d", 1)
+	q.where("b = $%d", 2)
+
+	q.page("SELECT * FROM t", 10, 0)
+	q.where("c = $%d", 3)
+
+	_, args := q.count("t")
+	if want := []any{1, 2, 3}; !sameArgs(args, want) {
+		t.Errorf("count() args = %v, want %v", args, want)
+	}
+}
+
+// sameArgs treats nil and empty argument lists as equal.
+func sameArgs(got, want []any) bool {
+	if len(got) == 0 && len(want) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(got, want)
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RecoveryCodeRepository interface {
+	Consume(ctx context.Context, adminID int, codeHash string) (bool, error)
+}
+
+type recoveryCodeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRecoveryCodeRepository(db *pgxpool.Pool) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+func (r *recoveryCodeRepository) Consume(ctx context.Context, adminID int, codeHash string) (bool, error) {
+	query := `UPDATE admin_recovery_codes SET used_at = NOW() WHERE admin_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	result, err := conn(ctx, r.db).Exec(ctx, query, adminID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume recovery code: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// replaceRecoveryCodes swaps the admin's recovery codes for codeHashes within
+// tx. With no hashes the admin is left without codes.
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, adminID int, codeHashes []string) error {
+	_, err := tx.Exec(ctx, `DELETE FROM admin_recovery_codes WHERE admin_id = $1`, adminID)
+	if err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	query := `INSERT INTO admin_recovery_codes (admin_id, code_hash) VALUES ($1, $2)`
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(ctx, query, adminID, hash); err != nil {
+			return fmt.Errorf("failed to create recovery code: %w", err)
+		}
+	}
+
+	return nil
+}
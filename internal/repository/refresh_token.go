@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrRefreshTokenRevoked = errors.New("refresh token already revoked")
+
+type RefreshTokenRepository interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*model.RefreshToken, error)
+	Create(ctx context.Context, token *model.RefreshToken) error
+	Rotate(ctx context.Context, oldID uuid.UUID, token *model.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeAllForAdmin(ctx context.Context, adminID int) error
+}
+
+type refreshTokenRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRefreshTokenRepository(db *pgxpool.Pool) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.RefreshToken, error) {
+	query := `SELECT id, family_id, admin_id, expires_at, revoked_at, replaced_by, created_at FROM refresh_tokens WHERE id = $1`
+
+	var token model.RefreshToken
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
+		&token.ID, &token.FamilyID, &token.AdminID, &token.ExpiresAt,
+		&token.RevokedAt, &token.ReplacedBy, &token.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", mapError(err))
+	}
+
+	return &token, nil
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	query := `INSERT INTO refresh_tokens (id, family_id, admin_id, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query, token.ID, token.FamilyID, token.AdminID, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *refreshTokenRepository) Rotate(ctx context.Context, oldID uuid.UUID, token *model.RefreshToken) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE refresh_tokens SET revoked_at = NOW(), replaced_by = $2 WHERE id = $1 AND revoked_at IS NULL`
+
+	result, err := tx.Exec(ctx, query, oldID, token.ID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRefreshTokenRevoked
+	}
+
+	query = `INSERT INTO refresh_tokens (id, family_id, admin_id, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err = tx.Exec(ctx, query, token.ID, token.FamilyID, token.AdminID, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query, familyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllForAdmin revokes every refresh token family of the admin, ending
+// all of its sessions once their access tokens expire.
+func (r *refreshTokenRepository) RevokeAllForAdmin(ctx context.Context, adminID int) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE admin_id = $1 AND revoked_at IS NULL`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query, adminID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RouteDistanceRepository interface {
+	List(ctx context.Context) ([]model.RouteDistance, error)
+}
+
+type routeDistanceRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRouteDistanceRepository(db *pgxpool.Pool) RouteDistanceRepository {
+	return &routeDistanceRepository{db: db}
+}
+
+func (r *routeDistanceRepository) List(ctx context.Context) ([]model.RouteDistance, error) {
+	query := `SELECT origin_terminal_id, destination_terminal_id, distance_km FROM route_distances`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query route distances: %w", err)
+	}
+	defer rows.Close()
+
+	distances, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.RouteDistance])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return distances, nil
+}
@@ -2,8 +2,11 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/aliffatulmf/mkp-eticket-service/internal/geo"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -11,11 +14,14 @@ import (
 )
 
 type TerminalRepository interface {
-	List(ctx context.Context) ([]model.Terminal, error)
-	FindByID(ctx context.Context, id uuid.UUID) (*model.Terminal, error)
+	List(ctx context.Context, filter *model.TerminalFilter) ([]model.Terminal, int, error)
+	FindByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*model.Terminal, error)
+	Nearby(ctx context.Context, filter *model.NearbyTerminalFilter) ([]model.NearbyTerminal, error)
+	ListActive(ctx context.Context) ([]model.Terminal, error)
 	Create(ctx context.Context, terminal *model.Terminal) error
 	Update(ctx context.Context, terminal *model.Terminal) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	SoftDelete(ctx context.Context, id uuid.UUID, deletedAt time.Time) error
+	Restore(ctx context.Context, id uuid.UUID, restoredAt time.Time) error
 }
 
 type terminalRepository struct {
@@ -26,53 +32,130 @@ func NewTerminalRepository(db *pgxpool.Pool) TerminalRepository {
 	return &terminalRepository{db: db}
 }
 
-func (r *terminalRepository) List(ctx context.Context) ([]model.Terminal, error) {
-	query := `SELECT id, code, name, address, is_active, created_at, updated_at FROM terminals ORDER BY name`
+var terminalSortColumns = map[string]string{
+	"code":       "code",
+	"name":       "name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+func (r *terminalRepository) List(ctx context.Context, filter *model.TerminalFilter) ([]model.Terminal, int, error) {
+	var q listQuery
+
+	if !filter.IncludeDeleted {
+		q.whereRaw("deleted_at IS NULL")
+	}
+	if filter.IsActive != nil {
+		q.where("is_active = $%d", *filter.IsActive)
+	}
+	q.search(filter.Search, "code", "name", "address")
+	q.sort(filter.Sort, filter.Order, terminalSortColumns, "name", "id")
+
+	countQuery, countArgs := q.count("terminals")
+
+	var total int
+	if err := conn(ctx, r.db).QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count terminals: %w", err)
+	}
+
+	query, args := q.page(`SELECT id, code, name, address, latitude, longitude, fare_zone, is_active, created_at, updated_at, version, deleted_at FROM terminals`, filter.Limit, filter.Offset)
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query terminals: %w", err)
+		return nil, 0, fmt.Errorf("failed to query terminals: %w", err)
 	}
 	defer rows.Close()
 
 	terms, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Terminal])
 	if err != nil {
-		return nil, fmt.Errorf("failed to collect rows: %w", err)
+		return nil, 0, fmt.Errorf("failed to collect rows: %w", err)
 	}
 
-	return terms, nil
+	return terms, total, nil
 }
 
-func (r *terminalRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Terminal, error) {
-	query := `SELECT id, code, name, address, is_active, created_at, updated_at FROM terminals WHERE id = $1`
+func (r *terminalRepository) FindByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*model.Terminal, error) {
+	query := `SELECT id, code, name, address, latitude, longitude, fare_zone, is_active, created_at, updated_at, version, deleted_at FROM terminals WHERE id = $1 AND ($2 OR deleted_at IS NULL)`
 
 	var terminal model.Terminal
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&terminal.ID, &terminal.Code, &terminal.Name, &terminal.Address,
-		&terminal.IsActive, &terminal.CreatedAt, &terminal.UpdatedAt,
+	err := conn(ctx, r.db).QueryRow(ctx, query, id, includeDeleted).Scan(
+		&terminal.ID, &terminal.Code, &terminal.Name, &terminal.Address, &terminal.Latitude, &terminal.Longitude, &terminal.FareZone,
+		&terminal.IsActive, &terminal.CreatedAt, &terminal.UpdatedAt, &terminal.Version, &terminal.DeletedAt,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get terminal: %w", err)
+		return nil, fmt.Errorf("failed to get terminal: %w", mapError(err))
 	}
 
 	return &terminal, nil
 }
 
+func (r *terminalRepository) ListActive(ctx context.Context) ([]model.Terminal, error) {
+	query := `SELECT id, code, name, address, latitude, longitude, fare_zone, is_active, created_at, updated_at, version, deleted_at FROM terminals WHERE is_active AND deleted_at IS NULL ORDER BY code`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query terminals: %w", err)
+	}
+	defer rows.Close()
+
+	terms, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Terminal])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return terms, nil
+}
+
+// Nearby returns active terminals within the radius of the given point,
+// closest first, using the haversine great-circle distance. Terminals outside
+// the bounding box of the radius are excluded before any distance is computed.
+func (r *terminalRepository) Nearby(ctx context.Context, filter *model.NearbyTerminalFilter) ([]model.NearbyTerminal, error) {
+	query := `SELECT * FROM (
+			SELECT id, code, name, address, latitude, longitude, fare_zone, is_active, created_at, updated_at, version, deleted_at,
+				6371 * 2 * ASIN(LEAST(1, SQRT(
+					POWER(SIN(RADIANS(latitude - $1) / 2), 2) +
+					COS(RADIANS($1)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - $2) / 2), 2)
+				))) AS distance_km
+			FROM terminals
+			WHERE deleted_at IS NULL AND is_active
+				AND latitude BETWEEN $5 AND $6 AND longitude BETWEEN $7 AND $8
+		) nearby
+		WHERE distance_km <= $3
+		ORDER BY distance_km
+		LIMIT $4`
+
+	box := geo.BoundingBox(*filter.Latitude, *filter.Longitude, filter.RadiusKm)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, *filter.Latitude, *filter.Longitude, filter.RadiusKm, filter.Limit,
+		box.MinLat, box.MaxLat, box.MinLng, box.MaxLng)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nearby terminals: %w", err)
+	}
+	defer rows.Close()
+
+	terms, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.NearbyTerminal])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return terms, nil
+}
+
 func (r *terminalRepository) Create(ctx context.Context, terminal *model.Terminal) error {
-	tx, err := r.db.Begin(ctx)
+	tx, err := begin(ctx, r.db)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	query := `INSERT INTO terminals (id, code, name, address, is_active, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	query := `INSERT INTO terminals (id, code, name, address, latitude, longitude, fare_zone, is_active, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING version`
 
-	_, err = tx.Exec(ctx, query,
-		terminal.ID, terminal.Code, terminal.Name, terminal.Address,
+	err = tx.QueryRow(ctx, query,
+		terminal.ID, terminal.Code, terminal.Name, terminal.Address, terminal.Latitude, terminal.Longitude, terminal.FareZone,
 		terminal.IsActive, terminal.CreatedAt, terminal.UpdatedAt,
-	)
+	).Scan(&terminal.Version)
 	if err != nil {
-		return fmt.Errorf("failed to create terminal: %w", err)
+		return fmt.Errorf("failed to create terminal: %w", mapWriteError(err))
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -83,19 +166,24 @@ func (r *terminalRepository) Create(ctx context.Context, terminal *model.Termina
 }
 
 func (r *terminalRepository) Update(ctx context.Context, terminal *model.Terminal) error {
-	tx, err := r.db.Begin(ctx)
+	tx, err := begin(ctx, r.db)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	query := `UPDATE terminals SET name = $2, address = $3, is_active = $4, updated_at = $5 WHERE id = $1`
+	query := `UPDATE terminals SET name = $2, address = $3, latitude = $4, longitude = $5, fare_zone = $6, is_active = $7, updated_at = $8, version = version + 1
+		WHERE id = $1 AND version = $9 AND deleted_at IS NULL RETURNING version`
 
-	_, err = tx.Exec(ctx, query,
-		terminal.ID, terminal.Name, terminal.Address, terminal.IsActive, terminal.UpdatedAt,
-	)
+	err = tx.QueryRow(ctx, query,
+		terminal.ID, terminal.Name, terminal.Address, terminal.Latitude, terminal.Longitude, terminal.FareZone,
+		terminal.IsActive, terminal.UpdatedAt, terminal.Version,
+	).Scan(&terminal.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("terminal with id %s at version %d: %w", terminal.ID, terminal.Version, ErrStale)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update terminal: %w", err)
+		return fmt.Errorf("failed to update terminal: %w", mapWriteError(err))
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -105,18 +193,61 @@ func (r *terminalRepository) Update(ctx context.Context, terminal *model.Termina
 	return nil
 }
 
-func (r *terminalRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	tx, err := r.db.Begin(ctx)
+// SoftDelete marks the terminal as deleted. The row is kept so that gates,
+// fares and historical transactions referencing it remain resolvable. A
+// terminal that is still a stop on a line cannot be deleted; the row lock
+// keeps a stop from being added to it while the stops are checked.
+func (r *terminalRepository) SoftDelete(ctx context.Context, id uuid.UUID, deletedAt time.Time) error {
+	tx, err := begin(ctx, r.db)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	query := `DELETE FROM terminals WHERE id = $1`
+	err = tx.QueryRow(ctx, `SELECT id FROM terminals WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("terminal with id %s: %w", id, mapError(err))
+	}
 
-	_, err = tx.Exec(ctx, query, id)
+	var onLine bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM line_stops WHERE terminal_id = $1)`, id).Scan(&onLine)
 	if err != nil {
-		return fmt.Errorf("failed to delete terminal: %w", err)
+		return fmt.Errorf("failed to check line stops: %w", err)
+	}
+
+	if onLine {
+		return fmt.Errorf("terminal with id %s is a stop on a line: %w", id, ErrReferenced)
+	}
+
+	query := `UPDATE terminals SET deleted_at = $2, updated_at = $2, version = version + 1 WHERE id = $1`
+
+	if _, err := tx.Exec(ctx, query, id, deletedAt); err != nil {
+		return fmt.Errorf("failed to delete terminal: %w", mapError(err))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *terminalRepository) Restore(ctx context.Context, id uuid.UUID, restoredAt time.Time) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE terminals SET deleted_at = NULL, updated_at = $2, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	result, err := tx.Exec(ctx, query, id, restoredAt)
+	if err != nil {
+		return fmt.Errorf("failed to restore terminal: %w", mapWriteError(err))
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("deleted terminal with id %s: %w", id, ErrNotFound)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TerminalScheduleRepository interface {
+	ListHours(ctx context.Context, terminalID uuid.UUID) ([]model.ServiceHours, error)
+	ReplaceHours(ctx context.Context, terminalID uuid.UUID, hours []model.ServiceHours) error
+	ListExceptions(ctx context.Context, terminalID uuid.UUID, from, to *string) ([]model.ServiceException, error)
+	UpsertException(ctx context.Context, terminalID uuid.UUID, exception *model.ServiceException) error
+	DeleteException(ctx context.Context, terminalID uuid.UUID, date string) error
+}
+
+type terminalScheduleRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTerminalScheduleRepository(db *pgxpool.Pool) TerminalScheduleRepository {
+	return &terminalScheduleRepository{db: db}
+}
+
+func (r *terminalScheduleRepository) ListHours(ctx context.Context, terminalID uuid.UUID) ([]model.ServiceHours, error) {
+	query := `SELECT day_of_week, to_char(opens_at, 'HH24:MI') AS opens_at, to_char(closes_at, 'HH24:MI') AS closes_at
+		FROM terminal_service_hours
+		WHERE terminal_id = $1
+		ORDER BY day_of_week`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, terminalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query service hours: %w", err)
+	}
+	defer rows.Close()
+
+	hours, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.ServiceHours])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return hours, nil
+}
+
+// ReplaceHours swaps the whole weekly schedule of a terminal in one
+// transaction.
+func (r *terminalScheduleRepository) ReplaceHours(ctx context.Context, terminalID uuid.UUID, hours []model.ServiceHours) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM terminal_service_hours WHERE terminal_id = $1`, terminalID); err != nil {
+		return fmt.Errorf("failed to delete service hours: %w", err)
+	}
+
+	query := `INSERT INTO terminal_service_hours (terminal_id, day_of_week, opens_at, closes_at) VALUES ($1, $2, $3::time, $4::time)`
+
+	for _, h := range hours {
+		if _, err := tx.Exec(ctx, query, terminalID, h.DayOfWeek, h.OpensAt, h.ClosesAt); err != nil {
+			return fmt.Errorf("failed to insert service hours: %w", mapWriteError(err))
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListExceptions returns the exceptions of a terminal between from and to
+// (inclusive, YYYY-MM-DD). Nil bounds are open-ended.
+func (r *terminalScheduleRepository) ListExceptions(ctx context.Context, terminalID uuid.UUID, from, to *string) ([]model.ServiceException, error) {
+	query := `SELECT to_char(service_date, 'YYYY-MM-DD') AS service_date,
+			to_char(opens_at, 'HH24:MI') AS opens_at, to_char(closes_at, 'HH24:MI') AS closes_at, note
+		FROM terminal_service_exceptions
+		WHERE terminal_id = $1
+			AND ($2::date IS NULL OR service_date >= $2::date)
+			AND ($3::date IS NULL OR service_date <= $3::date)
+		ORDER BY service_date`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, terminalID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query service exceptions: %w", err)
+	}
+	defer rows.Close()
+
+	exceptions, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.ServiceException])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect rows: %w", err)
+	}
+
+	return exceptions, nil
+}
+
+func (r *terminalScheduleRepository) UpsertException(ctx context.Context, terminalID uuid.UUID, exception *model.ServiceException) error {
+	query := `INSERT INTO terminal_service_exceptions (terminal_id, service_date, opens_at, closes_at, note)
+		VALUES ($1, $2::date, $3::time, $4::time, $5)
+		ON CONFLICT (terminal_id, service_date) DO UPDATE
+		SET opens_at = EXCLUDED.opens_at, closes_at = EXCLUDED.closes_at, note = EXCLUDED.note`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query, terminalID, exception.Date, exception.OpensAt, exception.ClosesAt, exception.Note)
+	if err != nil {
+		return fmt.Errorf("failed to upsert service exception: %w", mapWriteError(err))
+	}
+
+	return nil
+}
+
+func (r *terminalScheduleRepository) DeleteException(ctx context.Context, terminalID uuid.UUID, date string) error {
+	query := `DELETE FROM terminal_service_exceptions WHERE terminal_id = $1 AND service_date = $2::date`
+
+	result, err := conn(ctx, r.db).Exec(ctx, query, terminalID, date)
+	if err != nil {
+		return fmt.Errorf("failed to delete service exception: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("service exception on %s: %w", date, ErrNotFound)
+	}
+
+	return nil
+}
@@ -2,27 +2,76 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"sync"
 
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/auth"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrIncorrectPassword  = apperror.BadRequest("current password is incorrect")
+	ErrInvalidCredentials = apperror.Unauthorized("invalid username or password")
+	ErrAccountLocked      = apperror.TooManyRequests("too many failed login attempts, account temporarily locked")
+	ErrTooManyAttempts    = apperror.TooManyRequests("too many failed login attempts from this address")
+	ErrPasswordReused     = apperror.BadRequest("new password must differ from the current password")
+	ErrPasswordPolicy     = apperror.Validation("password does not meet policy", nil)
+)
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// dummyPasswordHash is compared against when the username does not exist so
+// that unknown and known usernames take the same time to reject.
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	})
+	return dummyHash
+}
+
 type AdminService interface {
+	List(ctx context.Context) ([]model.Admin, error)
 	FindByUsername(ctx context.Context, username string) (*model.Admin, error)
 	FindByID(ctx context.Context, id int) (*model.Admin, error)
-	Authenticate(ctx context.Context, req *model.AdminLoginRequest) (*model.Admin, error)
-	Create(ctx context.Context, admin *model.Admin) error
-	Delete(ctx context.Context, id int) error
+	Authenticate(ctx context.Context, req *model.AdminLoginRequest, ip string) (*model.Admin, error)
+	Create(ctx context.Context, actor *model.Actor, admin *model.Admin) error
+	Update(ctx context.Context, actor *model.Actor, id int, req *model.UpdateAdminRequest) (*model.Admin, error)
+	ChangePassword(ctx context.Context, actor *model.Actor, req *model.ChangePasswordRequest) error
+	Unlock(ctx context.Context, actor *model.Actor, id int) error
+	Delete(ctx context.Context, actor *model.Actor, id int) error
 }
 
 type adminService struct {
-	repo repository.AdminRepository
+	repo        repository.AdminRepository
+	attemptRepo repository.LoginAttemptRepository
+	refreshRepo repository.RefreshTokenRepository
+	tx          repository.Transactor
+	audit       AuditService
+	cfg         *config.Config
 }
 
-func NewAdminService(repo repository.AdminRepository) AdminService {
-	return &adminService{repo: repo}
+func NewAdminService(repo repository.AdminRepository, attemptRepo repository.LoginAttemptRepository, refreshRepo repository.RefreshTokenRepository, tx repository.Transactor, audit AuditService, cfg *config.Config) AdminService {
+	return &adminService{
+		repo:        repo,
+		attemptRepo: attemptRepo,
+		refreshRepo: refreshRepo,
+		tx:          tx,
+		audit:       audit,
+		cfg:         cfg,
+	}
+}
+
+func (s *adminService) List(ctx context.Context) ([]model.Admin, error) {
+	return s.repo.List(ctx)
 }
 
 func (s *adminService) FindByUsername(ctx context.Context, username string) (*model.Admin, error) {
@@ -33,21 +82,67 @@ func (s *adminService) FindByID(ctx context.Context, id int) (*model.Admin, erro
 	return s.repo.FindByID(ctx, id)
 }
 
-func (s *adminService) Authenticate(ctx context.Context, req *model.AdminLoginRequest) (*model.Admin, error) {
-	admin, err := s.repo.FindByUsername(ctx, req.Username)
+func (s *adminService) Authenticate(ctx context.Context, req *model.AdminLoginRequest, ip string) (*model.Admin, error) {
+	window := s.cfg.LoginLockoutDuration
+
+	ipFailures, err := s.attemptRepo.CountByIP(ctx, ip, window)
 	if err != nil {
-		return nil, fmt.Errorf("invalid username or password")
+		return nil, err
+	}
+	if ipFailures >= s.cfg.LoginMaxAttemptsPerIP {
+		return nil, ErrTooManyAttempts
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte(req.Password))
+	// Lockout is keyed on the submitted username rather than the admin row,
+	// so unknown usernames lock out exactly like real ones.
+	userFailures, err := s.attemptRepo.CountByUsername(ctx, req.Username, window)
 	if err != nil {
-		return nil, fmt.Errorf("invalid username or password")
+		return nil, err
+	}
+	if userFailures >= s.cfg.LoginMaxAttempts {
+		return nil, ErrAccountLocked
+	}
+
+	hash := dummyPasswordHash()
+	admin, err := s.repo.FindByUsername(ctx, req.Username)
+	switch {
+	case err == nil:
+		hash = []byte(admin.Password)
+	case !errors.Is(err, repository.ErrNotFound):
+		return nil, err
+	}
+
+	if bcrypt.CompareHashAndPassword(hash, []byte(req.Password)) != nil || admin == nil {
+		if err := s.attemptRepo.Record(ctx, req.Username, ip); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	// With two-factor enabled the failure count is only cleared once the
+	// second step succeeds, otherwise a known password would reset the
+	// lockout between code guesses.
+	if !admin.TOTPEnabled {
+		if err := s.attemptRepo.ClearByUsername(ctx, admin.Username); err != nil {
+			return nil, err
+		}
 	}
 
 	return admin, nil
 }
 
-func (s *adminService) Create(ctx context.Context, admin *model.Admin) error {
+func (s *adminService) Create(ctx context.Context, actor *model.Actor, admin *model.Admin) error {
+	if admin.Role == "" {
+		admin.Role = auth.RoleViewer
+	}
+
+	if err := s.validatePassword(admin.Password, admin.Username); err != nil {
+		return err
+	}
+
+	// Passwords set by another admin are temporary.
+	admin.MustChangePassword = true
+
 	hashed, err := bcrypt.GenerateFromPassword([]byte(admin.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
@@ -55,9 +150,145 @@ func (s *adminService) Create(ctx context.Context, admin *model.Admin) error {
 
 	admin.Password = string(hashed)
 
-	return s.repo.Create(ctx, admin)
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, admin); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionCreate, AuditEntityAdmin, strconv.Itoa(admin.ID), nil, admin)
+	})
 }
 
-func (s *adminService) Delete(ctx context.Context, id int) error {
-	return s.repo.Delete(ctx, id)
+// Update changes the admin's username, role and optionally password. A new
+// password or role ends the admin's sessions, so a refresh token issued
+// before cannot keep minting access tokens under the new role.
+func (s *adminService) Update(ctx context.Context, actor *model.Actor, id int, req *model.UpdateAdminRequest) (*model.Admin, error) {
+	admin, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *admin
+
+	if req.Role != "" {
+		admin.Role = req.Role
+	}
+
+	admin.Username = req.Username
+
+	if req.Password != "" {
+		if err := s.validatePassword(req.Password, admin.Username); err != nil {
+			return nil, err
+		}
+
+		hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		admin.Password = string(hashed)
+		admin.MustChangePassword = true
+	}
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, admin); err != nil {
+			return err
+		}
+		if req.Password != "" || admin.Role != before.Role {
+			if err := s.refreshRepo.RevokeAllForAdmin(ctx, admin.ID); err != nil {
+				return err
+			}
+		}
+		return s.audit.Record(ctx, actor, AuditActionUpdate, AuditEntityAdmin, strconv.Itoa(admin.ID), before, admin)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return admin, nil
+}
+
+// ChangePassword sets the actor's own password and ends all of its sessions,
+// including the current one, so a leaked refresh token stops working.
+func (s *adminService) ChangePassword(ctx context.Context, actor *model.Actor, req *model.ChangePasswordRequest) error {
+	admin, err := s.repo.FindByUsername(ctx, actor.Username)
+	if err != nil {
+		return err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte(req.CurrentPassword))
+	if err != nil {
+		return ErrIncorrectPassword
+	}
+
+	if req.NewPassword == req.CurrentPassword {
+		return ErrPasswordReused
+	}
+
+	if err := s.validatePassword(req.NewPassword, admin.Username); err != nil {
+		return err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdatePassword(ctx, admin.ID, string(hashed), false); err != nil {
+			return err
+		}
+		if err := s.refreshRepo.RevokeAllForAdmin(ctx, admin.ID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionChangePassword, AuditEntityAdmin, strconv.Itoa(admin.ID), nil, nil)
+	})
+}
+
+func (s *adminService) Unlock(ctx context.Context, actor *model.Actor, id int) error {
+	admin, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.attemptRepo.ClearByUsername(ctx, admin.Username); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionUnlock, AuditEntityAdmin, strconv.Itoa(admin.ID), nil, nil)
+	})
+}
+
+func (s *adminService) Delete(ctx context.Context, actor *model.Actor, id int) error {
+	admin, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionDelete, AuditEntityAdmin, strconv.Itoa(id), admin, nil)
+	})
+}
+
+func (s *adminService) validatePassword(password, username string) error {
+	err := s.passwordPolicy().Validate(password, username)
+
+	var policyErr *auth.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return ErrPasswordPolicy.WithDetails(policyErr.Violations)
+	}
+
+	return err
+}
+
+func (s *adminService) passwordPolicy() auth.PasswordPolicy {
+	return auth.PasswordPolicy{
+		MinLength:      s.cfg.PasswordMinLength,
+		RequireUpper:   s.cfg.PasswordRequireUpper,
+		RequireLower:   s.cfg.PasswordRequireLower,
+		RequireDigit:   s.cfg.PasswordRequireDigit,
+		RequireSymbol:  s.cfg.PasswordRequireSymbol,
+		RejectBreached: s.cfg.PasswordRejectBreached,
+	}
 }
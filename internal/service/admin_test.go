@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/auth"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeAdminRepository struct {
+	repository.AdminRepository
+
+	admin model.Admin
+}
+
+func (r *fakeAdminRepository) FindByID(ctx context.Context, id int) (*model.Admin, error) {
+	if id != r.admin.ID {
+		return nil, repository.ErrNotFound
+	}
+	admin := r.admin
+	return &admin, nil
+}
+
+func (r *fakeAdminRepository) FindByUsername(ctx context.Context, username string) (*model.Admin, error) {
+	if username != r.admin.Username {
+		return nil, repository.ErrNotFound
+	}
+	admin := r.admin
+	return &admin, nil
+}
+
+func (r *fakeAdminRepository) Update(ctx context.Context, admin *model.Admin) error {
+	r.admin = *admin
+	return nil
+}
+
+func (r *fakeAdminRepository) UpdatePassword(ctx context.Context, id int, password string, mustChange bool) error {
+	r.admin.Password = password
+	r.admin.MustChangePassword = mustChange
+	return nil
+}
+
+type fakeRefreshTokenRepository struct {
+	tokens map[uuid.UUID]*model.RefreshToken
+}
+
+func (r *fakeRefreshTokenRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.RefreshToken, error) {
+	token, ok := r.tokens[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (r *fakeRefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	r.tokens[token.ID] = token
+	return nil
+}
+
+func (r *fakeRefreshTokenRepository) Rotate(ctx context.Context, oldID uuid.UUID, token *model.RefreshToken) error {
+	old := r.tokens[oldID]
+	if old.RevokedAt != nil {
+		return repository.ErrRefreshTokenRevoked
+	}
+	now := time.Now()
+	old.RevokedAt, old.ReplacedBy = &now, &token.ID
+	r.tokens[token.ID] = token
+	return nil
+}
+
+func (r *fakeRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	return r.revoke(func(token *model.RefreshToken) bool { return token.FamilyID == familyID })
+}
+
+func (r *fakeRefreshTokenRepository) RevokeAllForAdmin(ctx context.Context, adminID int) error {
+	return r.revoke(func(token *model.RefreshToken) bool { return token.AdminID == adminID })
+}
+
+func (r *fakeRefreshTokenRepository) revoke(match func(*model.RefreshToken) bool) error {
+	now := time.Now()
+	for _, token := range r.tokens {
+		if match(token) && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+type fakeTransactor struct{}
+
+func (fakeTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeAuditService struct{}
+
+func (fakeAuditService) Record(ctx context.Context, actor *model.Actor, action, entityType, entityID string, before, after any) error {
+	return nil
+}
+
+func (fakeAuditService) List(ctx context.Context, filter *model.AuditLogFilter) ([]model.AuditLog, error) {
+	return nil, nil
+}
+
+func TestAdminUpdateRevokesRefreshTokens(t *testing.T) {
+	const password = "Current-Passw0rd"
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword() error = %v", err)
+	}
+
+	superadmin := &model.Actor{Username: "root", Role: auth.RoleSuperAdmin}
+
+	tests := []struct {
+		name    string
+		change  func(s AdminService, admin *model.Admin) error
+		wantErr error
+	}{
+		{
+			name: "password reset",
+			change: func(s AdminService, admin *model.Admin) error {
+				_, err := s.Update(context.Background(), superadmin, admin.ID, &model.UpdateAdminRequest{Username: admin.Username, Password: "Reset-Passw0rd-1"})
+				return err
+			},
+			wantErr: ErrRefreshTokenReused,
+		},
+		{
+			name: "role demotion",
+			change: func(s AdminService, admin *model.Admin) error {
+				_, err := s.Update(context.Background(), superadmin, admin.ID, &model.UpdateAdminRequest{Username: admin.Username, Role: auth.RoleViewer})
+				return err
+			},
+			wantErr: ErrRefreshTokenReused,
+		},
+		{
+			name: "own password change",
+			change: func(s AdminService, admin *model.Admin) error {
+				actor := &model.Actor{Username: admin.Username, Role: admin.Role}
+				return s.ChangePassword(context.Background(), actor, &model.ChangePasswordRequest{CurrentPassword: password, NewPassword: "Changed-Passw0rd-1"})
+			},
+			wantErr: ErrRefreshTokenReused,
+		},
+		{
+			name: "username only",
+			change: func(s AdminService, admin *model.Admin) error {
+				_, err := s.Update(context.Background(), superadmin, admin.ID, &model.UpdateAdminRequest{Username: "operator-two"})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			admin := model.Admin{ID: 7, Username: "operator", Password: string(hashed), Role: auth.RoleOperator}
+			adminRepo := &fakeAdminRepository{admin: admin}
+			refreshRepo := &fakeRefreshTokenRepository{tokens: map[uuid.UUID]*model.RefreshToken{}}
+			cfg := &config.Config{PasswordMinLength: 12}
+
+			admins := NewAdminService(adminRepo, nil, refreshRepo, fakeTransactor{}, fakeAuditService{}, cfg)
+			refreshTokens := NewRefreshTokenService(refreshRepo, adminRepo, auth.NewService("test-secret-at-least-32-bytes-long"))
+
+			token, err := refreshTokens.Issue(context.Background(), &admin)
+			if err != nil {
+				t.Fatalf("Issue() error = %v", err)
+			}
+
+			if err := tt.change(admins, &admin); err != nil {
+				t.Fatalf("change error = %v", err)
+			}
+
+			_, _, err = refreshTokens.Rotate(context.Background(), token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Rotate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
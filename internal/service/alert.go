@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/geo"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/notify"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	AlertTypeLowBalance         = "low_balance"
+	AlertTypeRepeatedFailedTaps = "repeated_failed_taps"
+	AlertTypeImpossibleTravel   = "impossible_travel"
+)
+
+const (
+	// alertBatchSize is the number of queued taps or undelivered alerts read
+	// per query.
+	alertBatchSize = 500
+
+	// maxTapAttempts and maxDeliveryAttempts bound the runs that retry a tap
+	// whose evaluation fails or an alert the notifier rejects. Past them the
+	// row is kept with its last error for an operator to inspect.
+	maxTapAttempts      = 5
+	maxDeliveryAttempts = 10
+
+	// minTravelKm ignores terminals close enough that coordinate error
+	// dominates the computed speed.
+	minTravelKm = 1.0
+)
+
+type AlertService interface {
+	List(ctx context.Context, filter *model.AlertFilter) ([]model.Alert, error)
+	Evaluate(ctx context.Context, tap *model.Tap) ([]model.Alert, error)
+	ConfigureQueue(ctx context.Context) error
+	ProcessQueue(ctx context.Context) (int, error)
+}
+
+type alertService struct {
+	repo         repository.AlertRepository
+	terminalRepo repository.TerminalRepository
+	notifier     notify.Notifier
+	cfg          *config.Config
+}
+
+func NewAlertService(repo repository.AlertRepository, terminalRepo repository.TerminalRepository, notifier notify.Notifier, cfg *config.Config) AlertService {
+	return &alertService{
+		repo:         repo,
+		terminalRepo: terminalRepo,
+		notifier:     notifier,
+		cfg:          cfg,
+	}
+}
+
+// NewAlertNotifier returns the notifier selected by ALERT_NOTIFIER, which
+// config.Load has already validated.
+func NewAlertNotifier(cfg *config.Config) notify.Notifier {
+	if cfg.AlertNotifier == config.NotifierWebhook {
+		return notify.NewWebhook(cfg.AlertWebhookURL, cfg.AlertWebhookTimeout)
+	}
+	return notify.NewLog()
+}
+
+func (s *alertService) List(ctx context.Context, filter *model.AlertFilter) ([]model.Alert, error) {
+	return s.repo.List(ctx, filter)
+}
+
+// Evaluate applies every alert rule to a single tap and returns the alerts it
+// raises without storing them.
+func (s *alertService) Evaluate(ctx context.Context, tap *model.Tap) ([]model.Alert, error) {
+	var alerts []model.Alert
+
+	if !tap.Accepted {
+		alert, err := s.checkFailedTaps(ctx, tap)
+		if err != nil {
+			return nil, err
+		}
+		if alert != nil {
+			alerts = append(alerts, *alert)
+		}
+		return alerts, nil
+	}
+
+	if alert := s.checkLowBalance(tap); alert != nil {
+		alerts = append(alerts, *alert)
+	}
+
+	alert, err := s.checkTravel(ctx, tap)
+	if err != nil {
+		return nil, err
+	}
+	if alert != nil {
+		alerts = append(alerts, *alert)
+	}
+
+	return alerts, nil
+}
+
+// ConfigureQueue enables the tap queue when the engine runs and disables and
+// empties it when ALERT_INTERVAL turns the engine off.
+func (s *alertService) ConfigureQueue(ctx context.Context) error {
+	return s.repo.SetQueueEnabled(ctx, s.cfg.AlertInterval > 0)
+}
+
+// ProcessQueue evaluates the queued taps and then delivers every alert not
+// yet delivered. Each tap is evaluated and stored on its own, so a tap that
+// fails is retried on later runs without holding up the rest, and the same
+// goes for alerts the notifier rejects. It returns the number of alerts
+// raised.
+func (s *alertService) ProcessQueue(ctx context.Context) (int, error) {
+	raised, err := s.evaluateQueue(ctx)
+	if err != nil {
+		return raised, err
+	}
+
+	return raised, s.deliver(ctx)
+}
+
+func (s *alertService) evaluateQueue(ctx context.Context) (int, error) {
+	raised := 0
+	var afterID int64
+
+	for {
+		taps, err := s.repo.Pending(ctx, afterID, alertBatchSize, maxTapAttempts)
+		if err != nil {
+			return raised, err
+		}
+
+		for i := range taps {
+			tap := &taps[i]
+			afterID = tap.ID
+
+			alerts, err := s.Evaluate(ctx, tap)
+			if err == nil {
+				var stored bool
+				stored, err = s.repo.Complete(ctx, tap.ID, alerts)
+				if stored {
+					raised += len(alerts)
+				}
+			}
+			if err != nil {
+				log.Printf("alert: failed to evaluate tap %d: %v", tap.ID, err)
+				if err := s.repo.Fail(ctx, tap.ID, err.Error()); err != nil {
+					return raised, err
+				}
+			}
+		}
+
+		if len(taps) < alertBatchSize {
+			return raised, nil
+		}
+	}
+}
+
+// deliver hands every undelivered alert to the notifier. Delivery is at least
+// once: an alert is marked delivered only after the notifier accepts it.
+func (s *alertService) deliver(ctx context.Context) error {
+	var afterID int64
+
+	for {
+		alerts, err := s.repo.Undelivered(ctx, afterID, alertBatchSize, maxDeliveryAttempts)
+		if err != nil {
+			return err
+		}
+
+		for i := range alerts {
+			alert := &alerts[i]
+			afterID = alert.ID
+
+			if err := s.notifier.Notify(ctx, alert); err != nil {
+				log.Printf("alert: failed to deliver alert %d: %v", alert.ID, err)
+				if err := s.repo.FailDelivery(ctx, alert.ID, err.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := s.repo.MarkDelivered(ctx, alert.ID, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		if len(alerts) < alertBatchSize {
+			return nil
+		}
+	}
+}
+
+// checkLowBalance alerts when a tap-out takes the balance from at least the
+// threshold to below it, so a card that stays low is reported once.
+func (s *alertService) checkLowBalance(tap *model.Tap) *model.Alert {
+	if tap.TapType != "tap_out" || tap.BalanceAfter == nil {
+		return nil
+	}
+
+	threshold := s.cfg.AlertLowBalance
+	after := *tap.BalanceAfter
+	if after >= threshold {
+		return nil
+	}
+	if tap.Amount != nil && after+*tap.Amount < threshold {
+		return nil
+	}
+
+	return newAlert(AlertTypeLowBalance, tap, map[string]any{
+		"balance_after": after,
+		"threshold":     threshold,
+	})
+}
+
+// checkFailedTaps alerts when the rejected taps at a gate within the window
+// reach the limit. A gate that already has such an alert within the window is
+// not reported again, so a burst raises one alert even when its taps are
+// retried or evaluated out of order.
+func (s *alertService) checkFailedTaps(ctx context.Context, tap *model.Tap) (*model.Alert, error) {
+	limit := s.cfg.AlertFailedTapLimit
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	window := s.cfg.AlertFailedTapWindow
+	from := tap.TappedAt.Add(-window)
+	count, err := s.repo.CountFailures(ctx, tap.GateID, from, tap.TappedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if count < limit {
+		return nil, nil
+	}
+
+	raised, err := s.repo.HasGateAlert(ctx, AlertTypeRepeatedFailedTaps, tap.GateID, from, tap.TappedAt)
+	if err != nil || raised {
+		return nil, err
+	}
+
+	details := map[string]any{
+		"failures": count,
+		"window":   window.String(),
+	}
+	if tap.CardNumber != nil {
+		details["card_number"] = *tap.CardNumber
+	}
+	if tap.Reason != nil {
+		details["reason"] = *tap.Reason
+	}
+
+	return newAlert(AlertTypeRepeatedFailedTaps, tap, details), nil
+}
+
+// checkTravel alerts when a card taps in at a terminal it could not have
+// reached from the terminal of its previous tap in the time between them.
+// Terminals without coordinates are not checked.
+func (s *alertService) checkTravel(ctx context.Context, tap *model.Tap) (*model.Alert, error) {
+	maxSpeed := s.cfg.AlertMaxTravelSpeedKmh
+	if tap.TapType != "tap_in" || tap.CardID == nil || maxSpeed <= 0 {
+		return nil, nil
+	}
+
+	previous, err := s.repo.PreviousTransaction(ctx, *tap.CardID, tap.SourceID, tap.TappedAt)
+	if err != nil || previous == nil || previous.TerminalID == tap.TerminalID {
+		return nil, err
+	}
+
+	distance, ok, err := s.terminalDistance(ctx, previous.TerminalID, tap.TerminalID)
+	if err != nil || !ok || distance < minTravelKm {
+		return nil, err
+	}
+
+	hours := tap.TappedAt.Sub(previous.TransactionTime).Hours()
+	speed := math.Inf(1)
+	if hours > 0 {
+		speed = distance / hours
+	}
+	if speed <= maxSpeed {
+		return nil, nil
+	}
+
+	details := map[string]any{
+		"previous_terminal_id": previous.TerminalID,
+		"previous_tap_at":      previous.TransactionTime,
+		"distance_km":          math.Round(distance*1000) / 1000,
+		"max_speed_kmh":        maxSpeed,
+	}
+	if !math.IsInf(speed, 1) {
+		details["speed_kmh"] = math.Round(speed*10) / 10
+	}
+
+	return newAlert(AlertTypeImpossibleTravel, tap, details), nil
+}
+
+func (s *alertService) terminalDistance(ctx context.Context, fromID, toID uuid.UUID) (float64, bool, error) {
+	from, err := s.terminalRepo.FindByID(ctx, fromID, true)
+	if err != nil {
+		return 0, false, err
+	}
+
+	to, err := s.terminalRepo.FindByID(ctx, toID, true)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if from.Latitude == nil || from.Longitude == nil || to.Latitude == nil || to.Longitude == nil {
+		return 0, false, nil
+	}
+
+	return geo.DistanceKm(*from.Latitude, *from.Longitude, *to.Latitude, *to.Longitude), true, nil
+}
+
+func newAlert(alertType string, tap *model.Tap, details map[string]any) *model.Alert {
+	data, _ := json.Marshal(details)
+
+	gateID, terminalID := tap.GateID, tap.TerminalID
+	return &model.Alert{
+		AlertType:  alertType,
+		CardID:     tap.CardID,
+		GateID:     &gateID,
+		TerminalID: &terminalID,
+		Details:    data,
+		OccurredAt: tap.TappedAt,
+	}
+}
@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/money"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/notify"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	gateA = uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	gateB = uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+
+	// Monas and Kota Tua are about 4.6 km apart; Bogor is about 45 km from
+	// Monas.
+	terminalMonas   = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	terminalKotaTua = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	terminalBogor   = uuid.MustParse("00000000-0000-0000-0000-000000000003")
+	terminalNearby  = uuid.MustParse("00000000-0000-0000-0000-000000000004")
+	terminalNoGPS   = uuid.MustParse("00000000-0000-0000-0000-000000000005")
+
+	testCard = uuid.MustParse("00000000-0000-0000-0000-0000000000c1")
+	tapTime  = time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+)
+
+var errLookup = errors.New("lookup failed")
+
+// fakeAlertRepository keeps the queue and alerts in memory. Methods the
+// engine does not use are left to the embedded nil interface.
+type fakeAlertRepository struct {
+	repository.AlertRepository
+
+	queue       []model.Tap
+	attempts    map[int64]int
+	alerts      []model.Alert
+	failures    map[uuid.UUID]int
+	failGate    *uuid.UUID
+	previous    *model.Transaction
+	deliveryTry map[int64]int
+}
+
+func newFakeAlertRepository() *fakeAlertRepository {
+	return &fakeAlertRepository{
+		attempts:    map[int64]int{},
+		failures:    map[uuid.UUID]int{},
+		deliveryTry: map[int64]int{},
+	}
+}
+
+func (r *fakeAlertRepository) Pending(ctx context.Context, afterID int64, limit, maxAttempts int) ([]model.Tap, error) {
+	var taps []model.Tap
+	for _, tap := range r.queue {
+		if tap.ID > afterID && r.attempts[tap.ID] < maxAttempts && len(taps) < limit {
+			taps = append(taps, tap)
+		}
+	}
+	return taps, nil
+}
+
+func (r *fakeAlertRepository) Complete(ctx context.Context, tapID int64, alerts []model.Alert) (bool, error) {
+	for i, tap := range r.queue {
+		if tap.ID != tapID {
+			continue
+		}
+		r.queue = append(r.queue[:i], r.queue[i+1:]...)
+		for j := range alerts {
+			alerts[j].ID = int64(len(r.alerts) + 1)
+			r.alerts = append(r.alerts, alerts[j])
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *fakeAlertRepository) Fail(ctx context.Context, tapID int64, reason string) error {
+	r.attempts[tapID]++
+	return nil
+}
+
+func (r *fakeAlertRepository) Undelivered(ctx context.Context, afterID int64, limit, maxAttempts int) ([]model.Alert, error) {
+	var alerts []model.Alert
+	for _, alert := range r.alerts {
+		if alert.DeliveredAt == nil && alert.ID > afterID && r.deliveryTry[alert.ID] < maxAttempts && len(alerts) < limit {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts, nil
+}
+
+func (r *fakeAlertRepository) MarkDelivered(ctx context.Context, id int64, deliveredAt time.Time) error {
+	r.alerts[id-1].DeliveredAt = &deliveredAt
+	return nil
+}
+
+func (r *fakeAlertRepository) FailDelivery(ctx context.Context, id int64, reason string) error {
+	r.deliveryTry[id]++
+	return nil
+}
+
+func (r *fakeAlertRepository) CountFailures(ctx context.Context, gateID uuid.UUID, from, to time.Time) (int, error) {
+	if r.failGate != nil && *r.failGate == gateID {
+		return 0, errLookup
+	}
+	return r.failures[gateID], nil
+}
+
+func (r *fakeAlertRepository) HasGateAlert(ctx context.Context, alertType string, gateID uuid.UUID, from, to time.Time) (bool, error) {
+	for _, alert := range r.alerts {
+		if alert.AlertType == alertType && alert.GateID != nil && *alert.GateID == gateID &&
+			alert.OccurredAt.After(from) && !alert.OccurredAt.After(to) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeAlertRepository) PreviousTransaction(ctx context.Context, cardID uuid.UUID, transactionID int64, before time.Time) (*model.Transaction, error) {
+	return r.previous, nil
+}
+
+type fakeTerminalRepository struct {
+	repository.TerminalRepository
+
+	terminals map[uuid.UUID]*model.Terminal
+}
+
+func (r *fakeTerminalRepository) FindByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*model.Terminal, error) {
+	terminal, ok := r.terminals[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return terminal, nil
+}
+
+// failingNotifier rejects every alert, like an unreachable webhook.
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(ctx context.Context, alert *model.Alert) error {
+	return errors.New("webhook responded with status 503")
+}
+
+func newTestTerminals() *fakeTerminalRepository {
+	at := func(id uuid.UUID, lat, lng float64) *model.Terminal {
+		return &model.Terminal{ID: id, Latitude: &lat, Longitude: &lng}
+	}
+
+	return &fakeTerminalRepository{terminals: map[uuid.UUID]*model.Terminal{
+		terminalMonas:   at(terminalMonas, -6.1754, 106.8272),
+		terminalKotaTua: at(terminalKotaTua, -6.1352, 106.8133),
+		terminalBogor:   at(terminalBogor, -6.5950, 106.7970),
+		terminalNearby:  at(terminalNearby, -6.1760, 106.8280),
+		terminalNoGPS:   {ID: terminalNoGPS},
+	}}
+}
+
+func newTestAlertService(repo *fakeAlertRepository, notifier notify.Notifier) *alertService {
+	return &alertService{
+		repo:         repo,
+		terminalRepo: newTestTerminals(),
+		notifier:     notifier,
+		cfg: &config.Config{
+			AlertInterval:          30 * time.Second,
+			AlertLowBalance:        money.FromMinor(500000),
+			AlertFailedTapLimit:    3,
+			AlertFailedTapWindow:   5 * time.Minute,
+			AlertMaxTravelSpeedKmh: 80,
+		},
+	}
+}
+
+func amount(minor int64) *money.Amount {
+	a := money.FromMinor(minor)
+	return &a
+}
+
+func TestCheckLowBalance(t *testing.T) {
+	tests := []struct {
+		name string
+		tap  model.Tap
+		want bool
+	}{
+		{name: "crosses the threshold", tap: model.Tap{TapType: "tap_out", Amount: amount(350000), BalanceAfter: amount(400000)}, want: true},
+		{name: "drops from exactly the threshold", tap: model.Tap{TapType: "tap_out", Amount: amount(100000), BalanceAfter: amount(400000)}, want: true},
+		{name: "stays above", tap: model.Tap{TapType: "tap_out", Amount: amount(350000), BalanceAfter: amount(500000)}},
+		{name: "already below", tap: model.Tap{TapType: "tap_out", Amount: amount(50000), BalanceAfter: amount(400000)}},
+		{name: "balance not reported", tap: model.Tap{TapType: "tap_out", Amount: amount(350000)}},
+		{name: "tap in", tap: model.Tap{TapType: "tap_in", BalanceAfter: amount(100)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestAlertService(newFakeAlertRepository(), notify.NewMemory())
+
+			alert := s.checkLowBalance(&tt.tap)
+			if (alert != nil) != tt.want {
+				t.Fatalf("checkLowBalance() = %v, want alert %v", alert, tt.want)
+			}
+			if alert != nil && alert.AlertType != AlertTypeLowBalance {
+				t.Errorf("alert type = %s, want %s", alert.AlertType, AlertTypeLowBalance)
+			}
+		})
+	}
+}
+
+func TestCheckFailedTaps(t *testing.T) {
+	existing := model.Alert{ID: 1, AlertType: AlertTypeRepeatedFailedTaps, GateID: &gateA, OccurredAt: tapTime.Add(-time.Minute)}
+	stale := model.Alert{ID: 1, AlertType: AlertTypeRepeatedFailedTaps, GateID: &gateA, OccurredAt: tapTime.Add(-10 * time.Minute)}
+	otherGate := model.Alert{ID: 1, AlertType: AlertTypeRepeatedFailedTaps, GateID: &gateB, OccurredAt: tapTime.Add(-time.Minute)}
+
+	tests := []struct {
+		name     string
+		failures int
+		limit    int
+		alerts   []model.Alert
+		want     bool
+	}{
+		{name: "below the limit", failures: 2, limit: 3},
+		{name: "reaches the limit", failures: 3, limit: 3, want: true},
+		{name: "past the limit without an alert", failures: 5, limit: 3, want: true},
+		{name: "already alerted within the window", failures: 5, limit: 3, alerts: []model.Alert{existing}},
+		{name: "previous alert outside the window", failures: 5, limit: 3, alerts: []model.Alert{stale}, want: true},
+		{name: "alert for another gate", failures: 3, limit: 3, alerts: []model.Alert{otherGate}, want: true},
+		{name: "disabled", failures: 10, limit: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeAlertRepository()
+			repo.failures[gateA] = tt.failures
+			repo.alerts = tt.alerts
+
+			s := newTestAlertService(repo, notify.NewMemory())
+			s.cfg.AlertFailedTapLimit = tt.limit
+
+			alert, err := s.checkFailedTaps(context.Background(), &model.Tap{GateID: gateA, TappedAt: tapTime})
+			if err != nil {
+				t.Fatalf("checkFailedTaps() error = %v", err)
+			}
+			if (alert != nil) != tt.want {
+				t.Fatalf("checkFailedTaps() = %v, want alert %v", alert, tt.want)
+			}
+			if alert == nil {
+				return
+			}
+
+			var details map[string]any
+			if err := json.Unmarshal(alert.Details, &details); err != nil {
+				t.Fatalf("details = %s: %v", alert.Details, err)
+			}
+			if got := int(details["failures"].(float64)); got != tt.failures {
+				t.Errorf("failures = %d, want %d", got, tt.failures)
+			}
+		})
+	}
+}
+
+func TestCheckTravel(t *testing.T) {
+	previous := func(terminalID uuid.UUID, ago time.Duration) *model.Transaction {
+		return &model.Transaction{ID: 1, TerminalID: terminalID, TransactionTime: tapTime.Add(-ago)}
+	}
+
+	tests := []struct {
+		name     string
+		tap      model.Tap
+		previous *model.Transaction
+		want     bool
+	}{
+		{name: "too fast", tap: model.Tap{TerminalID: terminalBogor}, previous: previous(terminalMonas, 10*time.Minute), want: true},
+		{name: "same instant", tap: model.Tap{TerminalID: terminalKotaTua}, previous: previous(terminalMonas, 0), want: true},
+		{name: "plausible speed", tap: model.Tap{TerminalID: terminalKotaTua}, previous: previous(terminalMonas, 10*time.Minute)},
+		{name: "same terminal", tap: model.Tap{TerminalID: terminalMonas}, previous: previous(terminalMonas, 0)},
+		{name: "terminals too close", tap: model.Tap{TerminalID: terminalNearby}, previous: previous(terminalMonas, 0)},
+		{name: "terminal without coordinates", tap: model.Tap{TerminalID: terminalNoGPS}, previous: previous(terminalMonas, 0)},
+		{name: "first tap of the card", tap: model.Tap{TerminalID: terminalBogor}},
+		{name: "tap out", tap: model.Tap{TapType: "tap_out", TerminalID: terminalBogor}, previous: previous(terminalMonas, time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeAlertRepository()
+			repo.previous = tt.previous
+
+			tap := tt.tap
+			if tap.TapType == "" {
+				tap.TapType = "tap_in"
+			}
+			tap.CardID = &testCard
+			tap.TappedAt = tapTime
+
+			s := newTestAlertService(repo, notify.NewMemory())
+
+			alert, err := s.checkTravel(context.Background(), &tap)
+			if err != nil {
+				t.Fatalf("checkTravel() error = %v", err)
+			}
+			if (alert != nil) != tt.want {
+				t.Fatalf("checkTravel() = %v, want alert %v", alert, tt.want)
+			}
+			if alert != nil && alert.AlertType != AlertTypeImpossibleTravel {
+				t.Errorf("alert type = %s, want %s", alert.AlertType, AlertTypeImpossibleTravel)
+			}
+		})
+	}
+}
+
+func TestProcessQueue(t *testing.T) {
+	lowBalance := model.Tap{ID: 1, Accepted: true, GateID: gateB, TerminalID: terminalMonas, TapType: "tap_out",
+		Amount: amount(350000), BalanceAfter: amount(400000), TappedAt: tapTime}
+	brokenLookup := model.Tap{ID: 2, GateID: gateA, TerminalID: terminalMonas, TapType: "tap_in", TappedAt: tapTime}
+	failedTap := model.Tap{ID: 3, GateID: gateB, TerminalID: terminalMonas, TapType: "tap_in", TappedAt: tapTime}
+	repeatedFailure := model.Tap{ID: 4, GateID: gateB, TerminalID: terminalMonas, TapType: "tap_in", TappedAt: tapTime.Add(time.Second)}
+
+	t.Run("a failing tap does not hold up the others", func(t *testing.T) {
+		repo := newFakeAlertRepository()
+		repo.queue = []model.Tap{lowBalance, brokenLookup, failedTap, repeatedFailure}
+		repo.failures[gateB] = 3
+		repo.failGate = &gateA
+
+		memory := notify.NewMemory()
+		s := newTestAlertService(repo, memory)
+
+		raised, err := s.ProcessQueue(context.Background())
+		if err != nil {
+			t.Fatalf("ProcessQueue() error = %v", err)
+		}
+		if raised != 2 {
+			t.Errorf("raised = %d, want 2", raised)
+		}
+
+		delivered := memory.Alerts()
+		if len(delivered) != 2 || delivered[0].AlertType != AlertTypeLowBalance || delivered[1].AlertType != AlertTypeRepeatedFailedTaps {
+			t.Errorf("delivered = %+v, want low_balance and one repeated_failed_taps", delivered)
+		}
+
+		if len(repo.queue) != 1 || repo.queue[0].ID != brokenLookup.ID {
+			t.Fatalf("queue = %+v, want only tap %d", repo.queue, brokenLookup.ID)
+		}
+		if repo.attempts[brokenLookup.ID] != 1 {
+			t.Errorf("attempts = %d, want 1", repo.attempts[brokenLookup.ID])
+		}
+	})
+
+	t.Run("a failing tap is given up after its attempts", func(t *testing.T) {
+		repo := newFakeAlertRepository()
+		repo.queue = []model.Tap{brokenLookup}
+		repo.failGate = &gateA
+
+		s := newTestAlertService(repo, notify.NewMemory())
+		for range maxTapAttempts + 2 {
+			if _, err := s.ProcessQueue(context.Background()); err != nil {
+				t.Fatalf("ProcessQueue() error = %v", err)
+			}
+		}
+
+		if repo.attempts[brokenLookup.ID] != maxTapAttempts {
+			t.Errorf("attempts = %d, want %d", repo.attempts[brokenLookup.ID], maxTapAttempts)
+		}
+	})
+
+	t.Run("undelivered alerts are retried", func(t *testing.T) {
+		repo := newFakeAlertRepository()
+		repo.queue = []model.Tap{lowBalance}
+
+		s := newTestAlertService(repo, failingNotifier{})
+		if _, err := s.ProcessQueue(context.Background()); err != nil {
+			t.Fatalf("ProcessQueue() error = %v", err)
+		}
+		if repo.alerts[0].DeliveredAt != nil || repo.deliveryTry[1] != 1 {
+			t.Fatalf("alert = %+v after %d attempts, want undelivered after 1", repo.alerts[0], repo.deliveryTry[1])
+		}
+
+		memory := notify.NewMemory()
+		s.notifier = memory
+		if _, err := s.ProcessQueue(context.Background()); err != nil {
+			t.Fatalf("ProcessQueue() error = %v", err)
+		}
+		if got := memory.Alerts(); len(got) != 1 || got[0].ID != 1 {
+			t.Errorf("delivered = %+v, want alert 1", got)
+		}
+		if repo.alerts[0].DeliveredAt == nil {
+			t.Error("alert 1 is not marked delivered")
+		}
+	})
+}
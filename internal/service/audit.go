@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+)
+
+const (
+	AuditActionCreate         = "create"
+	AuditActionUpdate         = "update"
+	AuditActionDelete         = "delete"
+	AuditActionRestore        = "restore"
+	AuditActionChangePassword = "change_password"
+	AuditActionUnlock         = "unlock"
+	AuditActionEnableTOTP     = "enable_totp"
+	AuditActionDisableTOTP    = "disable_totp"
+	AuditActionGenerate       = "generate"
+	AuditActionRenew          = "renew"
+	AuditActionReplace        = "replace"
+	AuditActionExpire         = "expire"
+	AuditActionTransfer       = "transfer"
+	AuditActionRegister       = "register"
+	AuditActionUnregister     = "unregister"
+	AuditActionIssueKey       = "issue_key"
+)
+
+const (
+	AuditEntityTerminal         = "terminal"
+	AuditEntityAdmin            = "admin"
+	AuditEntityFareMatrix       = "fare_matrix"
+	AuditEntityLine             = "line"
+	AuditEntityFareZone         = "fare_zone"
+	AuditEntityZoneFare         = "zone_fare"
+	AuditEntityServiceHours     = "terminal_service_hours"
+	AuditEntityServiceException = "terminal_service_exception"
+	AuditEntityCard             = "card"
+	AuditEntityCardHolder       = "card_holder"
+	AuditEntityGate             = "gate"
+)
+
+// SystemActor performs the changes made by background jobs.
+var SystemActor = &model.Actor{Username: "system"}
+
+type AuditService interface {
+	Record(ctx context.Context, actor *model.Actor, action, entityType, entityID string, before, after any) error
+	List(ctx context.Context, filter *model.AuditLogFilter) ([]model.AuditLog, error)
+}
+
+type auditService struct {
+	repo repository.AuditLogRepository
+}
+
+func NewAuditService(repo repository.AuditLogRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+// Record appends an audit entry for a mutation. Call it with the context of
+// the Transactor running the mutation so the entry is written in the same
+// transaction: a change is never committed without its entry.
+func (s *auditService) Record(ctx context.Context, actor *model.Actor, action, entityType, entityID string, before, after any) error {
+	entry := &model.AuditLog{
+		Actor:      actor.Username,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     marshalAuditState(before),
+		After:      marshalAuditState(after),
+	}
+
+	if actor.RequestID != "" {
+		entry.RequestID = &actor.RequestID
+	}
+	if actor.IPAddress != "" {
+		entry.IPAddress = &actor.IPAddress
+	}
+
+	if err := s.repo.Create(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record %s %s/%s: %w", action, entityType, entityID, err)
+	}
+
+	return nil
+}
+
+func (s *auditService) List(ctx context.Context, filter *model.AuditLogFilter) ([]model.AuditLog, error) {
+	return s.repo.List(ctx, filter)
+}
+
+func marshalAuditState(state any) json.RawMessage {
+	if state == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
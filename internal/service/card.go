@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	CardStatusActive  = "active"
+	CardStatusBlocked = "blocked"
+	CardStatusExpired = "expired"
+)
+
+var (
+	ErrCardExpired        = apperror.Conflict("card has expired and cannot change status")
+	ErrCardReplaced       = apperror.Conflict("card has already been replaced")
+	ErrCardBlockedRenewal = apperror.Conflict("blocked cards can only be renewed with a replacement card")
+	ErrReplacementReason  = apperror.Conflict("replacing a blocked card requires a reason")
+	ErrCardNumberTaken    = apperror.Conflict("card number is already in use")
+	ErrSameCard           = apperror.BadRequest("source and target card must differ")
+	ErrTargetCardInactive = apperror.Conflict("target card is not active")
+)
+
+const (
+	LedgerReasonBalanceTransfer = "balance_transfer"
+	LedgerReasonCardReplacement = "card_replacement"
+)
+
+// replacementAttempts bounds the retries when a generated card number
+// collides with an existing one.
+const replacementAttempts = 3
+
+type CardService interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Card, error)
+	UpdateStatus(ctx context.Context, actor *model.Actor, id uuid.UUID, req *model.UpdateCardStatusRequest) (*model.Card, error)
+	Renew(ctx context.Context, actor *model.Actor, id uuid.UUID, req *model.RenewCardRequest) (*model.CardRenewal, error)
+	ExpireDue(ctx context.Context) (int, error)
+	TransferBalance(ctx context.Context, actor *model.Actor, id uuid.UUID, req *model.TransferBalanceRequest) (*model.BalanceTransfer, error)
+}
+
+type cardService struct {
+	repo          repository.CardRepository
+	tx            repository.Transactor
+	audit         AuditService
+	location      *time.Location
+	validityYears int
+}
+
+func NewCardService(repo repository.CardRepository, tx repository.Transactor, audit AuditService, cfg *config.Config) CardService {
+	return &cardService{
+		repo:          repo,
+		tx:            tx,
+		audit:         audit,
+		location:      cfg.ServiceLocation,
+		validityYears: cfg.CardValidityYears,
+	}
+}
+
+func (s *cardService) FindByID(ctx context.Context, id uuid.UUID) (*model.Card, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+// UpdateStatus blocks or unblocks a card. The change is picked up by the
+// hotlist through a trigger on cards, so it reaches gates with the next
+// delta poll.
+func (s *cardService) UpdateStatus(ctx context.Context, actor *model.Actor, id uuid.UUID, req *model.UpdateCardStatusRequest) (*model.Card, error) {
+	card, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if card.ReplacedBy != nil {
+		return nil, ErrCardReplaced
+	}
+
+	if card.Status == req.Status {
+		return card, nil
+	}
+
+	if card.Status == CardStatusExpired {
+		return nil, ErrCardExpired
+	}
+
+	var updated *model.Card
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdateStatus(ctx, id, req.Status, time.Now()); err != nil {
+			return err
+		}
+
+		var err error
+		if updated, err = s.repo.FindByID(ctx, id); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, actor, AuditActionUpdate, AuditEntityCard, id.String(), card, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// Renew either extends the expiry date of a card or, with ReplaceCard,
+// issues a new card number that takes over the remaining balance. The old
+// card is then blocked and points to its replacement.
+func (s *cardService) Renew(ctx context.Context, actor *model.Actor, id uuid.UUID, req *model.RenewCardRequest) (*model.CardRenewal, error) {
+	card, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if card.ReplacedBy != nil {
+		return nil, ErrCardReplaced
+	}
+
+	if req.ReplaceCard {
+		return s.replace(ctx, actor, card, req)
+	}
+
+	if card.Status == CardStatusBlocked {
+		return nil, ErrCardBlockedRenewal
+	}
+
+	before := *card
+
+	expiry := card.ExpiryDate
+	if today := s.today(); expiry.Before(today) {
+		expiry = today
+	}
+
+	card.ExpiryDate = expiry.AddDate(s.validityYears, 0, 0)
+	card.Status = CardStatusActive
+	card.UpdatedAt = time.Now()
+
+	var renewed *model.Card
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Extend(ctx, card); err != nil {
+			if errors.Is(err, repository.ErrCardBlocked) {
+				return ErrCardBlockedRenewal.Wrap(err)
+			}
+			return err
+		}
+
+		var err error
+		if renewed, err = s.repo.FindByID(ctx, id); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, actor, AuditActionRenew, AuditEntityCard, id.String(), before, renewed)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CardRenewal{Card: renewed}, nil
+}
+
+// ExpireDue marks every active card past its expiry date as expired. It is
+// run periodically by the card expiry job.
+func (s *cardService) ExpireDue(ctx context.Context) (int, error) {
+	var ids []uuid.UUID
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if ids, err = s.repo.ExpireDue(ctx, s.today()); err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			err := s.audit.Record(ctx, SystemActor, AuditActionExpire, AuditEntityCard, id.String(), nil, map[string]string{"status": CardStatusExpired})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(ids), nil
+}
+
+// replace issues a successor card. A blocked card may have been blocked for
+// fraud rather than loss, so moving its balance needs a reason, which is kept
+// in the audit log.
+func (s *cardService) replace(ctx context.Context, actor *model.Actor, card *model.Card, req *model.RenewCardRequest) (*model.CardRenewal, error) {
+	var reason *string
+	if req.Reason != nil && strings.TrimSpace(*req.Reason) != "" {
+		reason = req.Reason
+	}
+
+	if card.Status == CardStatusBlocked && reason == nil {
+		return nil, ErrReplacementReason
+	}
+
+	cardNumber := req.CardNumber
+	today := s.today()
+	now := time.Now()
+
+	replacement := &model.Card{
+		ID:         uuid.New(),
+		Status:     CardStatusActive,
+		IssuedDate: today,
+		ExpiryDate: today.AddDate(s.validityYears, 0, 0),
+		HolderID:   card.HolderID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	renewal := &model.CardRenewal{}
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		for attempt := 1; ; attempt++ {
+			var err error
+			if cardNumber != nil {
+				replacement.CardNumber = *cardNumber
+			} else if replacement.CardNumber, err = generateCardNumber(); err != nil {
+				return err
+			}
+
+			_, err = s.repo.Replace(ctx, card.ID, replacement, LedgerReasonCardReplacement, reason != nil)
+			if err == nil {
+				break
+			}
+			if errors.Is(err, repository.ErrCardBlocked) {
+				return ErrReplacementReason.Wrap(err)
+			}
+			if !errors.Is(err, repository.ErrConflict) {
+				return err
+			}
+			if cardNumber != nil || attempt >= replacementAttempts {
+				return ErrCardNumberTaken.Wrap(err)
+			}
+		}
+
+		var err error
+		if renewal.ReplacedCard, err = s.repo.FindByID(ctx, card.ID); err != nil {
+			return err
+		}
+		if renewal.Card, err = s.repo.FindByID(ctx, replacement.ID); err != nil {
+			return err
+		}
+
+		after := map[string]any{"card": renewal.ReplacedCard, "reason": reason}
+		if err := s.audit.Record(ctx, actor, AuditActionReplace, AuditEntityCard, card.ID.String(), card, after); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionCreate, AuditEntityCard, replacement.ID.String(), nil, renewal.Card)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return renewal, nil
+}
+
+// TransferBalance blocks a lost card and moves its whole balance to another
+// active card. The source card is blocked even when its balance is zero.
+func (s *cardService) TransferBalance(ctx context.Context, actor *model.Actor, id uuid.UUID, req *model.TransferBalanceRequest) (*model.BalanceTransfer, error) {
+	if id == req.TargetCardID {
+		return nil, ErrSameCard
+	}
+
+	source, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.ReplacedBy != nil {
+		return nil, ErrCardReplaced
+	}
+
+	target, err := s.repo.FindByID(ctx, req.TargetCardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Status != CardStatusActive || target.ReplacedBy != nil {
+		return nil, ErrTargetCardInactive
+	}
+
+	transfer := &model.BalanceTransfer{}
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		transfer.Entries, err = s.repo.Transfer(ctx, id, req.TargetCardID, LedgerReasonBalanceTransfer, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if len(transfer.Entries) > 0 {
+			transfer.Amount = transfer.Entries[0].Amount
+		}
+
+		if transfer.Source, err = s.repo.FindByID(ctx, id); err != nil {
+			return err
+		}
+		if transfer.Target, err = s.repo.FindByID(ctx, req.TargetCardID); err != nil {
+			return err
+		}
+
+		if err := s.audit.Record(ctx, actor, AuditActionTransfer, AuditEntityCard, source.ID.String(), source, transfer.Source); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionTransfer, AuditEntityCard, target.ID.String(), target, transfer.Target)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// today is the current calendar date in the service time zone, as a UTC
+// midnight so it compares directly with DATE columns.
+func (s *cardService) today() time.Time {
+	now := time.Now().In(s.location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func generateCardNumber() (string, error) {
+	digits := make([]byte, 16)
+	for i := range digits {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + n.Int64())
+	}
+	return string(digits), nil
+}
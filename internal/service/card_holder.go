@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/auth"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var ErrLookupCriteria = apperror.BadRequest("at least one of phone, email or id_number is required")
+
+type CardHolderService interface {
+	FindByID(ctx context.Context, actor *model.Actor, id uuid.UUID) (*model.CardHolderDetail, error)
+	FindByCard(ctx context.Context, actor *model.Actor, cardID uuid.UUID) (*model.CardHolder, error)
+	Lookup(ctx context.Context, actor *model.Actor, req *model.CardHolderLookupRequest) ([]model.CardHolderDetail, error)
+	Register(ctx context.Context, actor *model.Actor, cardID uuid.UUID, req *model.RegisterCardHolderRequest) (*model.CardHolder, error)
+	Update(ctx context.Context, actor *model.Actor, id uuid.UUID, req *model.UpdateCardHolderRequest) (*model.CardHolder, error)
+	Unregister(ctx context.Context, actor *model.Actor, cardID uuid.UUID) error
+}
+
+type cardHolderService struct {
+	repo    repository.CardHolderRepository
+	tx      repository.Transactor
+	audit   AuditService
+	hashKey []byte
+}
+
+func NewCardHolderService(repo repository.CardHolderRepository, tx repository.Transactor, audit AuditService, cfg *config.Config) CardHolderService {
+	return &cardHolderService{
+		repo:    repo,
+		tx:      tx,
+		audit:   audit,
+		hashKey: []byte(cfg.CardHolderHashKey),
+	}
+}
+
+func (s *cardHolderService) FindByID(ctx context.Context, actor *model.Actor, id uuid.UUID) (*model.CardHolderDetail, error) {
+	holder, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.detail(ctx, actor, holder)
+}
+
+func (s *cardHolderService) FindByCard(ctx context.Context, actor *model.Actor, cardID uuid.UUID) (*model.CardHolder, error) {
+	holder, err := s.repo.FindByCard(ctx, cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	return presentCardHolder(actor, holder), nil
+}
+
+func (s *cardHolderService) Lookup(ctx context.Context, actor *model.Actor, req *model.CardHolderLookupRequest) ([]model.CardHolderDetail, error) {
+	if req.Phone == nil && req.Email == nil && req.IDNumber == nil {
+		return nil, ErrLookupCriteria
+	}
+
+	var idNumberHash *string
+	if req.IDNumber != nil {
+		hash := s.hashIDNumber(*req.IDNumber)
+		idNumberHash = &hash
+	}
+
+	holders, err := s.repo.Lookup(ctx, req.Phone, req.Email, idNumberHash)
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]model.CardHolderDetail, 0, len(holders))
+	for i := range holders {
+		detail, err := s.detail(ctx, actor, &holders[i])
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, *detail)
+	}
+
+	return details, nil
+}
+
+// Register attaches a holder to the card. Holders are matched by identity
+// number, so registering a second card for the same person reuses the holder;
+// the name and contact details in req are then ignored in favour of the
+// stored ones, which only Update changes.
+func (s *cardHolderService) Register(ctx context.Context, actor *model.Actor, cardID uuid.UUID, req *model.RegisterCardHolderRequest) (*model.CardHolder, error) {
+	now := time.Now()
+
+	holder := &model.CardHolder{
+		ID:           uuid.New(),
+		Name:         strings.TrimSpace(req.Name),
+		Phone:        req.Phone,
+		Email:        req.Email,
+		IDNumberHash: s.hashIDNumber(req.IDNumber),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Register(ctx, cardID, holder); err != nil {
+			return err
+		}
+
+		// The audit log is readable by fewer roles than holder data, but it
+		// is also kept far longer, so only the masked form is recorded.
+		return s.audit.Record(ctx, actor, AuditActionRegister, AuditEntityCard, cardID.String(), nil, maskCardHolder(*holder))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return presentCardHolder(actor, holder), nil
+}
+
+// Update changes the holder's name and contact details. The audit entry
+// records the replaced values next to the new ones, masked like every other
+// holder entry.
+func (s *cardHolderService) Update(ctx context.Context, actor *model.Actor, id uuid.UUID, req *model.UpdateCardHolderRequest) (*model.CardHolder, error) {
+	holder := &model.CardHolder{
+		ID:        id,
+		Name:      strings.TrimSpace(req.Name),
+		Phone:     req.Phone,
+		Email:     req.Email,
+		UpdatedAt: time.Now(),
+	}
+
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.Update(ctx, holder)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionUpdate, AuditEntityCardHolder, id.String(), maskCardHolder(*before), maskCardHolder(*holder))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return presentCardHolder(actor, holder), nil
+}
+
+func (s *cardHolderService) Unregister(ctx context.Context, actor *model.Actor, cardID uuid.UUID) error {
+	holder, err := s.repo.FindByCard(ctx, cardID)
+	if err != nil {
+		return err
+	}
+
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Unregister(ctx, cardID, time.Now()); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionUnregister, AuditEntityCard, cardID.String(), maskCardHolder(*holder), nil)
+	})
+}
+
+func (s *cardHolderService) detail(ctx context.Context, actor *model.Actor, holder *model.CardHolder) (*model.CardHolderDetail, error) {
+	cards, err := s.repo.ListCards(ctx, holder.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CardHolderDetail{Holder: presentCardHolder(actor, holder), Cards: cards}, nil
+}
+
+// presentCardHolder returns holder as the actor may see it: unchanged for
+// roles with holders:read, masked for everyone else.
+func presentCardHolder(actor *model.Actor, holder *model.CardHolder) *model.CardHolder {
+	if auth.HasPermission(actor.Role, auth.PermHoldersRead) {
+		return holder
+	}
+
+	masked := maskCardHolder(*holder)
+	return &masked
+}
+
+// hashIDNumber keys the hash so that identity numbers, which have little
+// entropy, cannot be recovered by hashing every possible value.
+func (s *cardHolderService) hashIDNumber(idNumber string) string {
+	mac := hmac.New(sha256.New, s.hashKey)
+	mac.Write([]byte(strings.ToUpper(strings.TrimSpace(idNumber))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func maskCardHolder(holder model.CardHolder) model.CardHolder {
+	holder.Masked = true
+
+	words := strings.Fields(holder.Name)
+	for i, word := range words {
+		words[i] = keepPrefix(word, 1)
+	}
+	holder.Name = strings.Join(words, " ")
+
+	if holder.Phone != nil {
+		phone := maskPhone(*holder.Phone)
+		holder.Phone = &phone
+	}
+
+	if holder.Email != nil {
+		email := maskEmail(*holder.Email)
+		holder.Email = &email
+	}
+
+	return holder
+}
+
+// maskPhone keeps the country code and the last four digits, e.g.
+// +62*******7890.
+func maskPhone(phone string) string {
+	if len(phone) <= 7 {
+		return strings.Repeat("*", len(phone))
+	}
+	return phone[:3] + strings.Repeat("*", len(phone)-7) + phone[len(phone)-4:]
+}
+
+// maskEmail keeps the first character of the local part and the domain.
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return keepPrefix(email, 1)
+	}
+	return keepPrefix(local, 1) + "@" + domain
+}
+
+func keepPrefix(s string, n int) string {
+	count := utf8.RuneCountInString(s)
+	if count <= n {
+		return s
+	}
+
+	runes := []rune(s)
+	return string(runes[:n]) + strings.Repeat("*", count-n)
+}
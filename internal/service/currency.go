@@ -0,0 +1,31 @@
+package service
+
+import (
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/money"
+)
+
+var ErrAmountPrecision = apperror.BadRequest("amount has more decimals than the currency allows")
+
+// newFormatter formats amounts in the deployment currency and locale.
+func newFormatter(cfg *config.Config) money.Formatter {
+	return money.NewFormatter(cfg.Currency, cfg.Locale)
+}
+
+// checkAmounts rejects amounts that cannot be paid in the deployment
+// currency, e.g. 0.50 when the currency is IDR. Fields are keyed by their
+// JSON name in the error details.
+func checkAmounts(currency money.Currency, amounts map[string]*money.Amount) error {
+	details := map[string]string{}
+	for field, amount := range amounts {
+		if amount != nil && !currency.Valid(*amount) {
+			details[field] = field + " must be a whole multiple of " + currency.Unit().String() + " " + currency.Code
+		}
+	}
+
+	if len(details) > 0 {
+		return ErrAmountPrecision.WithDetails(details)
+	}
+	return nil
+}
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/geo"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/money"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrFareGenerationChanged = apperror.Conflict("fares or route distances changed since the preview")
+	ErrFareOutOfRange        = apperror.BadRequest("fare parameters produce an amount out of range")
+)
+
+const (
+	FareStatusAdded     = "added"
+	FareStatusChanged   = "changed"
+	FareStatusUnchanged = "unchanged"
+)
+
+const (
+	DistanceSourceRoute       = "route"
+	DistanceSourceCoordinates = "coordinates"
+)
+
+type FareService interface {
+	PreviewGeneration(ctx context.Context, req *model.GenerateFaresRequest) (*model.FareGenerationResult, error)
+	ApplyGeneration(ctx context.Context, actor *model.Actor, req *model.ApplyFaresRequest) (*model.FareGenerationResult, error)
+	Quote(ctx context.Context, originID, destinationID uuid.UUID) (*model.FareQuote, error)
+	ListZones(ctx context.Context) ([]model.FareZone, error)
+	UpsertZone(ctx context.Context, actor *model.Actor, zone int, req *model.UpsertFareZoneRequest) (*model.FareZone, error)
+	ListZoneFares(ctx context.Context) ([]model.ZoneFare, error)
+	UpsertZoneFare(ctx context.Context, actor *model.Actor, zonesTravelled int, req *model.UpsertZoneFareRequest) (*model.ZoneFare, error)
+}
+
+type fareService struct {
+	repo         repository.FareRepository
+	terminalRepo repository.TerminalRepository
+	routeRepo    repository.RouteDistanceRepository
+	zoneRepo     repository.FareZoneRepository
+	calculator   FareCalculator
+	tx           repository.Transactor
+	audit        AuditService
+	cfg          *config.Config
+	formatter    money.Formatter
+}
+
+func NewFareService(repo repository.FareRepository, terminalRepo repository.TerminalRepository, routeRepo repository.RouteDistanceRepository, zoneRepo repository.FareZoneRepository, calculator FareCalculator, tx repository.Transactor, audit AuditService, cfg *config.Config) FareService {
+	return &fareService{
+		repo:         repo,
+		terminalRepo: terminalRepo,
+		routeRepo:    routeRepo,
+		zoneRepo:     zoneRepo,
+		calculator:   calculator,
+		tx:           tx,
+		audit:        audit,
+		cfg:          cfg,
+		formatter:    newFormatter(cfg),
+	}
+}
+
+func (s *fareService) PreviewGeneration(ctx context.Context, req *model.GenerateFaresRequest) (*model.FareGenerationResult, error) {
+	return s.generate(ctx, req)
+}
+
+// ApplyGeneration recomputes the generation with the fare matrix and route
+// distances locked and applies it only if it is still the one previewed.
+func (s *fareService) ApplyGeneration(ctx context.Context, actor *model.Actor, req *model.ApplyFaresRequest) (*model.FareGenerationResult, error) {
+	var result *model.FareGenerationResult
+
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.LockForGeneration(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		result, err = s.generate(ctx, &req.GenerateFaresRequest)
+		if err != nil {
+			return err
+		}
+
+		if result.Version != req.Version {
+			return ErrFareGenerationChanged
+		}
+
+		now := time.Now()
+
+		var fares []model.FareMatrix
+		for _, change := range result.Changes {
+			if change.Status == FareStatusUnchanged {
+				continue
+			}
+			fares = append(fares, model.FareMatrix{
+				OriginTerminalID:      change.OriginTerminalID,
+				DestinationTerminalID: change.DestinationTerminalID,
+				FareAmount:            change.NewAmount,
+				IsActive:              true,
+				UpdatedAt:             now,
+			})
+		}
+
+		if len(fares) == 0 {
+			return nil
+		}
+
+		if err := s.repo.Upsert(ctx, fares); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, actor, AuditActionGenerate, AuditEntityFareMatrix, "", nil, map[string]any{
+			"base_fare":   result.BaseFare,
+			"per_km_rate": result.PerKmRate,
+			"rounding":    result.Rounding,
+			"version":     result.Version,
+			"summary":     result.Summary,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Applied = true
+
+	return result, nil
+}
+
+func (s *fareService) Quote(ctx context.Context, originID, destinationID uuid.UUID) (*model.FareQuote, error) {
+	quote, err := s.calculator.Calculate(ctx, originID, destinationID)
+	if err != nil {
+		return nil, err
+	}
+
+	quote.Currency = s.formatter.Currency.Code
+	quote.FareDisplay = s.formatter.Format(quote.FareAmount)
+
+	return quote, nil
+}
+
+func (s *fareService) ListZones(ctx context.Context) ([]model.FareZone, error) {
+	return s.zoneRepo.ListZones(ctx)
+}
+
+func (s *fareService) UpsertZone(ctx context.Context, actor *model.Actor, zone int, req *model.UpsertFareZoneRequest) (*model.FareZone, error) {
+	fareZone := &model.FareZone{
+		Zone:      zone,
+		Name:      req.Name,
+		UpdatedAt: time.Now(),
+	}
+
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.zoneRepo.UpsertZone(ctx, fareZone); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionUpdate, AuditEntityFareZone, strconv.Itoa(zone), nil, fareZone)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fareZone, nil
+}
+
+func (s *fareService) ListZoneFares(ctx context.Context) ([]model.ZoneFare, error) {
+	return s.zoneRepo.ListZoneFares(ctx)
+}
+
+func (s *fareService) UpsertZoneFare(ctx context.Context, actor *model.Actor, zonesTravelled int, req *model.UpsertZoneFareRequest) (*model.ZoneFare, error) {
+	if err := checkAmounts(s.formatter.Currency, map[string]*money.Amount{"fare_amount": req.FareAmount}); err != nil {
+		return nil, err
+	}
+
+	fare := &model.ZoneFare{
+		ZonesTravelled: zonesTravelled,
+		FareAmount:     *req.FareAmount,
+		UpdatedAt:      time.Now(),
+	}
+
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.zoneRepo.UpsertZoneFare(ctx, fare); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionUpdate, AuditEntityZoneFare, strconv.Itoa(zonesTravelled), nil, fare)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fare, nil
+}
+
+// generate computes the fare for every ordered pair of active terminals and
+// compares it with the current fare matrix. Route distances take precedence
+// over the straight-line distance between terminal coordinates.
+func (s *fareService) generate(ctx context.Context, req *model.GenerateFaresRequest) (*model.FareGenerationResult, error) {
+	amounts := map[string]*money.Amount{"base_fare": req.BaseFare, "rounding": req.Rounding}
+	if err := checkAmounts(s.formatter.Currency, amounts); err != nil {
+		return nil, err
+	}
+
+	result := &model.FareGenerationResult{
+		Currency:  s.formatter.Currency.Code,
+		BaseFare:  valueOr(req.BaseFare, s.cfg.FareBaseAmount),
+		PerKmRate: valueOr(req.PerKmRate, s.cfg.FarePerKmRate),
+		Rounding:  valueOr(req.Rounding, s.cfg.FareRounding),
+		Changes:   []model.FareChange{},
+		Skipped:   []model.SkippedFare{},
+	}
+
+	terminals, err := s.terminalRepo.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := s.routeRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	routeDistances := make(map[[2]uuid.UUID]float64, len(routes))
+	for _, route := range routes {
+		routeDistances[[2]uuid.UUID{route.OriginTerminalID, route.DestinationTerminalID}] = route.DistanceKm
+	}
+
+	currentFares := make(map[[2]uuid.UUID]model.FareMatrix, len(current))
+	for _, fare := range current {
+		currentFares[[2]uuid.UUID{fare.OriginTerminalID, fare.DestinationTerminalID}] = fare
+	}
+
+	for _, origin := range terminals {
+		for _, destination := range terminals {
+			if origin.ID == destination.ID {
+				continue
+			}
+
+			distance, source, ok := pairDistance(routeDistances, &origin, &destination)
+			if !ok {
+				result.Skipped = append(result.Skipped, model.SkippedFare{
+					OriginCode:      origin.Code,
+					DestinationCode: destination.Code,
+					Reason:          "no route distance or coordinates",
+				})
+				result.Summary.Skipped++
+				continue
+			}
+
+			amount, err := fareAmount(distance, result.BaseFare, result.PerKmRate, result.Rounding, s.formatter.Currency.Unit())
+			if err != nil {
+				return nil, ErrFareOutOfRange
+			}
+
+			change := model.FareChange{
+				OriginTerminalID:      origin.ID,
+				OriginCode:            origin.Code,
+				DestinationTerminalID: destination.ID,
+				DestinationCode:       destination.Code,
+				DistanceKm:            math.Round(distance*1000) / 1000,
+				DistanceSource:        source,
+				NewAmount:             amount,
+				Status:                FareStatusAdded,
+			}
+
+			if fare, ok := currentFares[[2]uuid.UUID{origin.ID, destination.ID}]; ok {
+				change.CurrentAmount = &fare.FareAmount
+				change.Status = FareStatusChanged
+				if fare.IsActive && fare.FareAmount == change.NewAmount {
+					change.Status = FareStatusUnchanged
+				}
+			}
+
+			switch change.Status {
+			case FareStatusAdded:
+				result.Summary.Added++
+			case FareStatusChanged:
+				result.Summary.Changed++
+			default:
+				result.Summary.Unchanged++
+			}
+
+			result.Changes = append(result.Changes, change)
+		}
+	}
+
+	version, err := generationVersion(result)
+	if err != nil {
+		return nil, err
+	}
+	result.Version = version
+
+	return result, nil
+}
+
+// generationVersion hashes the parameters and every computed fare, so any
+// change to the terminals, route distances or current fares between a
+// preview and its apply yields a different version.
+func generationVersion(result *model.FareGenerationResult) (string, error) {
+	content, err := json.Marshal(struct {
+		BaseFare  money.Amount        `json:"base_fare"`
+		PerKmRate money.Amount        `json:"per_km_rate"`
+		Rounding  money.Amount        `json:"rounding"`
+		Changes   []model.FareChange  `json:"changes"`
+		Skipped   []model.SkippedFare `json:"skipped"`
+	}{result.BaseFare, result.PerKmRate, result.Rounding, result.Changes, result.Skipped})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash fare generation: %w", err)
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func pairDistance(routes map[[2]uuid.UUID]float64, origin, destination *model.Terminal) (float64, string, bool) {
+	if distance, ok := routes[[2]uuid.UUID{origin.ID, destination.ID}]; ok {
+		return distance, DistanceSourceRoute, true
+	}
+	if distance, ok := routes[[2]uuid.UUID{destination.ID, origin.ID}]; ok {
+		return distance, DistanceSourceRoute, true
+	}
+
+	if origin.Latitude == nil || origin.Longitude == nil || destination.Latitude == nil || destination.Longitude == nil {
+		return 0, "", false
+	}
+
+	return geo.DistanceKm(*origin.Latitude, *origin.Longitude, *destination.Latitude, *destination.Longitude), DistanceSourceCoordinates, true
+}
+
+// fareAmount rounds the distance-based fare up to the next multiple of
+// rounding, then to the smallest unit of the currency. Only the distance
+// component is fractional, it is rounded to whole cents before any further
+// arithmetic.
+func fareAmount(distanceKm float64, baseFare, perKmRate, rounding, unit money.Amount) (money.Amount, error) {
+	distanceFare, err := perKmRate.MulFloat(distanceKm)
+	if err != nil {
+		return 0, err
+	}
+
+	fare, err := baseFare.Add(distanceFare)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, step := range []money.Amount{rounding, unit} {
+		rounded := fare.RoundUp(step)
+		if rounded < fare {
+			return 0, money.ErrOverflow
+		}
+		fare = rounded
+	}
+
+	return fare, nil
+}
+
+func valueOr(value *money.Amount, fallback money.Amount) money.Amount {
+	if value != nil {
+		return *value
+	}
+	return fallback
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrFareNotDefined       = apperror.NotFound("no fare is defined for this journey")
+	ErrTerminalWithoutZone  = apperror.BadRequest("terminal has no fare zone assigned")
+	ErrFareTerminalNotFound = apperror.NotFound("origin or destination terminal not found")
+	ErrFareTerminalInactive = apperror.BadRequest("origin or destination terminal is not active")
+)
+
+// FareCalculator prices a journey between two terminals. The implementation
+// is chosen per deployment through config.Config.FareModel.
+type FareCalculator interface {
+	Calculate(ctx context.Context, originID, destinationID uuid.UUID) (*model.FareQuote, error)
+}
+
+func NewFareCalculator(fareRepo repository.FareRepository, zoneRepo repository.FareZoneRepository, terminalRepo repository.TerminalRepository, cfg *config.Config) FareCalculator {
+	if cfg.FareModel == config.FareModelZone {
+		return &zoneFareCalculator{zoneRepo: zoneRepo, terminalRepo: terminalRepo}
+	}
+	return &matrixFareCalculator{fareRepo: fareRepo, terminalRepo: terminalRepo}
+}
+
+// matrixFareCalculator looks the journey up in the origin-destination
+// fare_matrix.
+type matrixFareCalculator struct {
+	fareRepo     repository.FareRepository
+	terminalRepo repository.TerminalRepository
+}
+
+func (c *matrixFareCalculator) Calculate(ctx context.Context, originID, destinationID uuid.UUID) (*model.FareQuote, error) {
+	// Fare rows outlive their terminals, so a journey is only quoted while
+	// both ends are in service, as in the zone model.
+	for _, id := range []uuid.UUID{originID, destinationID} {
+		if _, err := fareTerminal(ctx, c.terminalRepo, id); err != nil {
+			return nil, err
+		}
+	}
+
+	fare, err := c.fareRepo.Find(ctx, originID, destinationID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrFareNotDefined
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !fare.IsActive {
+		return nil, ErrFareNotDefined
+	}
+
+	return &model.FareQuote{
+		OriginTerminalID:      originID,
+		DestinationTerminalID: destinationID,
+		FareAmount:            fare.FareAmount,
+		Model:                 config.FareModelMatrix,
+	}, nil
+}
+
+// zoneFareCalculator prices by the number of zones travelled, counting both
+// the origin and destination zone.
+type zoneFareCalculator struct {
+	zoneRepo     repository.FareZoneRepository
+	terminalRepo repository.TerminalRepository
+}
+
+func (c *zoneFareCalculator) Calculate(ctx context.Context, originID, destinationID uuid.UUID) (*model.FareQuote, error) {
+	originZone, err := c.terminalZone(ctx, originID)
+	if err != nil {
+		return nil, err
+	}
+
+	destinationZone, err := c.terminalZone(ctx, destinationID)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := originZone - destinationZone
+	if zones < 0 {
+		zones = -zones
+	}
+	zones++
+
+	fare, err := c.zoneRepo.FindZoneFare(ctx, zones)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrFareNotDefined.WithDetails(map[string]int{"zones_travelled": zones})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.FareQuote{
+		OriginTerminalID:      originID,
+		DestinationTerminalID: destinationID,
+		FareAmount:            fare.FareAmount,
+		Model:                 config.FareModelZone,
+		ZonesTravelled:        &zones,
+	}, nil
+}
+
+func (c *zoneFareCalculator) terminalZone(ctx context.Context, terminalID uuid.UUID) (int, error) {
+	terminal, err := fareTerminal(ctx, c.terminalRepo, terminalID)
+	if err != nil {
+		return 0, err
+	}
+
+	if terminal.FareZone == nil {
+		return 0, ErrTerminalWithoutZone.WithDetails(map[string]string{"terminal_id": terminalID.String()})
+	}
+
+	return *terminal.FareZone, nil
+}
+
+// fareTerminal returns the terminal at one end of a journey, rejecting one
+// that is deleted or not active.
+func fareTerminal(ctx context.Context, terminalRepo repository.TerminalRepository, id uuid.UUID) (*model.Terminal, error) {
+	terminal, err := terminalRepo.FindByID(ctx, id, false)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrFareTerminalNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !terminal.IsActive {
+		return nil, ErrFareTerminalInactive.WithDetails(map[string]string{"terminal_id": id.String()})
+	}
+
+	return terminal, nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/money"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+type fakeFareRepository struct {
+	repository.FareRepository
+
+	fares map[[2]uuid.UUID]model.FareMatrix
+}
+
+func (r *fakeFareRepository) Find(ctx context.Context, originID, destinationID uuid.UUID) (*model.FareMatrix, error) {
+	fare, ok := r.fares[[2]uuid.UUID{originID, destinationID}]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &fare, nil
+}
+
+type fakeFareZoneRepository struct {
+	repository.FareZoneRepository
+
+	fares map[int]money.Amount
+}
+
+func (r *fakeFareZoneRepository) FindZoneFare(ctx context.Context, zonesTravelled int) (*model.ZoneFare, error) {
+	amount, ok := r.fares[zonesTravelled]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &model.ZoneFare{ZonesTravelled: zonesTravelled, FareAmount: amount}, nil
+}
+
+func TestFareCalculator(t *testing.T) {
+	origin := uuid.MustParse("00000000-0000-0000-0000-0000000000f1")
+	destination := uuid.MustParse("00000000-0000-0000-0000-0000000000f2")
+	inactive := uuid.MustParse("00000000-0000-0000-0000-0000000000f3")
+	deleted := uuid.MustParse("00000000-0000-0000-0000-0000000000f4")
+
+	zone := func(n int) *int { return &n }
+
+	// The terminal repository hides deleted terminals, so deleted is absent.
+	terminals := &fakeTerminalRepository{terminals: map[uuid.UUID]*model.Terminal{
+		origin:      {ID: origin, IsActive: true, FareZone: zone(1)},
+		destination: {ID: destination, IsActive: true, FareZone: zone(2)},
+		inactive:    {ID: inactive, IsActive: false, FareZone: zone(2)},
+	}}
+
+	fares := &fakeFareRepository{fares: map[[2]uuid.UUID]model.FareMatrix{}}
+	for _, to := range []uuid.UUID{destination, inactive, deleted} {
+		fares.fares[[2]uuid.UUID{origin, to}] = model.FareMatrix{FareAmount: 350000, IsActive: true}
+	}
+
+	zones := &fakeFareZoneRepository{fares: map[int]money.Amount{2: 400000}}
+
+	tests := []struct {
+		name        string
+		model       string
+		destination uuid.UUID
+		want        money.Amount
+		wantErr     error
+	}{
+		{name: "matrix", model: config.FareModelMatrix, destination: destination, want: 350000},
+		{name: "matrix to an inactive terminal", model: config.FareModelMatrix, destination: inactive, wantErr: ErrFareTerminalInactive},
+		{name: "matrix to a deleted terminal", model: config.FareModelMatrix, destination: deleted, wantErr: ErrFareTerminalNotFound},
+		{name: "zone", model: config.FareModelZone, destination: destination, want: 400000},
+		{name: "zone to an inactive terminal", model: config.FareModelZone, destination: inactive, wantErr: ErrFareTerminalInactive},
+		{name: "zone to a deleted terminal", model: config.FareModelZone, destination: deleted, wantErr: ErrFareTerminalNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calculator := NewFareCalculator(fares, zones, terminals, &config.Config{FareModel: tt.model})
+
+			quote, err := calculator.Calculate(context.Background(), origin, tt.destination)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Calculate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if quote.FareAmount != tt.want {
+				t.Errorf("fare = %d, want %d", quote.FareAmount, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/money"
+)
+
+func TestFareAmount(t *testing.T) {
+	tests := []struct {
+		name       string
+		distanceKm float64
+		baseFare   money.Amount
+		perKmRate  money.Amount
+		rounding   money.Amount
+		unit       money.Amount
+		want       money.Amount
+		wantErr    error
+	}{
+		{name: "rounded to the step", distanceKm: 7.3, baseFare: 300000, perKmRate: 50000, rounding: 50000, unit: 100, want: 700000},
+		{name: "no rounding step", distanceKm: 7.3, baseFare: 300000, perKmRate: 50000, unit: 100, want: 665000},
+		{name: "rounded to the currency unit", distanceKm: 1.234, baseFare: 0, perKmRate: 1000, unit: 100, want: 1300},
+		{name: "zero distance", baseFare: 300000, perKmRate: 50000, rounding: 50000, unit: 100, want: 300000},
+		{name: "distance fare overflows", distanceKm: 3, perKmRate: math.MaxInt64 / 2, unit: 1, wantErr: money.ErrOverflow},
+		{name: "sum overflows", distanceKm: 1, baseFare: math.MaxInt64 - 10, perKmRate: 100, unit: 1, wantErr: money.ErrOverflow},
+		{name: "rounding overflows", baseFare: math.MaxInt64 - 1, rounding: 100, unit: 1, wantErr: money.ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fareAmount(tt.distanceKm, tt.baseFare, tt.perKmRate, tt.rounding, tt.unit)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("fareAmount() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("fareAmount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
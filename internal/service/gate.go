@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidGateKey = apperror.Unauthorized("invalid gate key")
+
+type GateService interface {
+	IssueKey(ctx context.Context, actor *model.Actor, id uuid.UUID) (*model.GateKey, error)
+	Authenticate(ctx context.Context, key string) (*model.Gate, error)
+	ReportTapFailure(ctx context.Context, id uuid.UUID, req *model.ReportTapFailureRequest) (*model.TapFailure, error)
+}
+
+type gateService struct {
+	repo  repository.GateRepository
+	tx    repository.Transactor
+	audit AuditService
+}
+
+func NewGateService(repo repository.GateRepository, tx repository.Transactor, audit AuditService) GateService {
+	return &gateService{
+		repo:  repo,
+		tx:    tx,
+		audit: audit,
+	}
+}
+
+// IssueKey generates a new API key for the gate, replacing any previous one.
+// The key is only returned here; the gate must store it.
+func (s *gateService) IssueKey(ctx context.Context, actor *model.Actor, id uuid.UUID) (*model.GateKey, error) {
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate gate key: %w", err)
+	}
+
+	key := &model.GateKey{
+		GateID:   id,
+		Key:      base64.RawURLEncoding.EncodeToString(raw),
+		IssuedAt: time.Now(),
+	}
+
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SetKeyHash(ctx, id, hashGateKey(key.Key), key.IssuedAt); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionIssueKey, AuditEntityGate, id.String(), nil, map[string]any{
+			"issued_at": key.IssuedAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Authenticate returns the active gate owning key.
+func (s *gateService) Authenticate(ctx context.Context, key string) (*model.Gate, error) {
+	gate, err := s.repo.FindByKeyHash(ctx, hashGateKey(key))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidGateKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !gate.IsActive {
+		return nil, ErrInvalidGateKey
+	}
+
+	return gate, nil
+}
+
+// ReportTapFailure records a tap rejected by the gate at the gate's terminal,
+// where the alert engine picks it up.
+func (s *gateService) ReportTapFailure(ctx context.Context, id uuid.UUID, req *model.ReportTapFailureRequest) (*model.TapFailure, error) {
+	gate, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	failure := &model.TapFailure{
+		CardNumber: req.CardNumber,
+		GateID:     gate.ID,
+		TerminalID: gate.TerminalID,
+		TapType:    req.TapType,
+		Reason:     req.Reason,
+		OccurredAt: time.Now(),
+	}
+	if req.OccurredAt != nil {
+		failure.OccurredAt = *req.OccurredAt
+	}
+
+	if err := s.repo.CreateTapFailure(ctx, failure); err != nil {
+		return nil, err
+	}
+
+	return failure, nil
+}
+
+// hashGateKey uses a plain SHA-256: keys are 256 random bits, so unlike
+// passwords they need no slow hash, and the digest can be looked up directly.
+func hashGateKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+type fakeGateRepository struct {
+	repository.GateRepository
+
+	gates    map[uuid.UUID]*model.Gate
+	failures []model.TapFailure
+}
+
+func (r *fakeGateRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Gate, error) {
+	gate, ok := r.gates[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return gate, nil
+}
+
+func (r *fakeGateRepository) CreateTapFailure(ctx context.Context, failure *model.TapFailure) error {
+	failure.ID = int64(len(r.failures) + 1)
+	r.failures = append(r.failures, *failure)
+	return nil
+}
+
+func TestReportTapFailure(t *testing.T) {
+	gateID := uuid.MustParse("00000000-0000-0000-0000-0000000000b1")
+	terminalID := uuid.MustParse("00000000-0000-0000-0000-0000000000b2")
+	occurredAt := time.Date(2026, 3, 2, 7, 30, 0, 0, time.UTC)
+	cardNumber := "1234567890123456"
+
+	tests := []struct {
+		name    string
+		gateID  uuid.UUID
+		req     model.ReportTapFailureRequest
+		wantErr error
+	}{
+		{
+			name:   "reported time",
+			gateID: gateID,
+			req:    model.ReportTapFailureRequest{CardNumber: &cardNumber, TapType: "tap_in", Reason: "card_blocked", OccurredAt: &occurredAt},
+		},
+		{
+			name:   "unreadable card at the time of the report",
+			gateID: gateID,
+			req:    model.ReportTapFailureRequest{TapType: "tap_out", Reason: "unreadable_card"},
+		},
+		{
+			name:    "unknown gate",
+			gateID:  uuid.MustParse("00000000-0000-0000-0000-0000000000b3"),
+			req:     model.ReportTapFailureRequest{TapType: "tap_in", Reason: "card_blocked"},
+			wantErr: repository.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeGateRepository{gates: map[uuid.UUID]*model.Gate{
+				gateID: {ID: gateID, TerminalID: terminalID, IsActive: true},
+			}}
+			s := NewGateService(repo, fakeTransactor{}, fakeAuditService{})
+
+			start := time.Now()
+			failure, err := s.ReportTapFailure(context.Background(), tt.gateID, &tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReportTapFailure() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(repo.failures) != 0 {
+					t.Errorf("stored %d failures, want none", len(repo.failures))
+				}
+				return
+			}
+
+			if len(repo.failures) != 1 {
+				t.Fatalf("stored %d failures, want 1", len(repo.failures))
+			}
+			if failure.GateID != gateID || failure.TerminalID != terminalID {
+				t.Errorf("failure at gate %s, terminal %s, want gate %s, terminal %s", failure.GateID, failure.TerminalID, gateID, terminalID)
+			}
+			if tt.req.OccurredAt != nil && !failure.OccurredAt.Equal(*tt.req.OccurredAt) {
+				t.Errorf("occurred_at = %v, want %v", failure.OccurredAt, *tt.req.OccurredAt)
+			}
+			if tt.req.OccurredAt == nil && failure.OccurredAt.Before(start) {
+				t.Errorf("occurred_at = %v, want the time of the report", failure.OccurredAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/bloom"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+)
+
+type HotlistService interface {
+	Changes(ctx context.Context, since int64, limit int) (*model.HotlistDelta, error)
+	Bloom(ctx context.Context) (int64, []byte, error)
+	Version(ctx context.Context) (int64, error)
+}
+
+type hotlistService struct {
+	repo repository.HotlistRepository
+	cfg  *config.Config
+}
+
+func NewHotlistService(repo repository.HotlistRepository, cfg *config.Config) HotlistService {
+	return &hotlistService{
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+// Changes returns the status changes after since, oldest first. Gates apply
+// them in order and poll again from the returned version.
+func (s *hotlistService) Changes(ctx context.Context, since int64, limit int) (*model.HotlistDelta, error) {
+	entries, err := s.repo.ListSince(ctx, since, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	delta := &model.HotlistDelta{Since: since, Version: since}
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+		delta.HasMore = true
+	}
+
+	for i := range entries {
+		entries[i].Listed = entries[i].Status == CardStatusBlocked
+	}
+
+	if len(entries) > 0 {
+		delta.Version = entries[len(entries)-1].Version
+	}
+
+	delta.Changes = entries
+
+	return delta, nil
+}
+
+// Version returns the current hotlist version without building anything, so
+// a gate that is up to date can be answered cheaply.
+func (s *hotlistService) Version(ctx context.Context) (int64, error) {
+	return s.repo.Version(ctx)
+}
+
+// Bloom exports the blocked card numbers as a Bloom filter together with the
+// hotlist version it reflects.
+func (s *hotlistService) Bloom(ctx context.Context) (int64, []byte, error) {
+	version, numbers, err := s.repo.Snapshot(ctx, CardStatusBlocked)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	filter := bloom.New(len(numbers), s.cfg.HotlistFalsePositiveRate)
+	for _, number := range numbers {
+		filter.Add(number)
+	}
+
+	data, err := filter.MarshalBinary()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return version, data, nil
+}
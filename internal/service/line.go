@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDuplicateLineStop = apperror.BadRequest("a terminal can only appear once on a line")
+	ErrUnknownStop       = apperror.BadRequest("line stop refers to an unknown terminal")
+)
+
+type LineService interface {
+	List(ctx context.Context) ([]model.Line, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Line, error)
+	Create(ctx context.Context, actor *model.Actor, req *model.CreateLineRequest) (*model.Line, error)
+	Update(ctx context.Context, actor *model.Actor, id uuid.UUID, req *model.UpdateLineRequest) (*model.Line, error)
+	ReplaceStops(ctx context.Context, actor *model.Actor, id uuid.UUID, req *model.ReplaceLineStopsRequest) (*model.Line, error)
+	Delete(ctx context.Context, actor *model.Actor, id uuid.UUID) error
+}
+
+type lineService struct {
+	repo         repository.LineRepository
+	terminalRepo repository.TerminalRepository
+	tx           repository.Transactor
+	audit        AuditService
+}
+
+func NewLineService(repo repository.LineRepository, terminalRepo repository.TerminalRepository, tx repository.Transactor, audit AuditService) LineService {
+	return &lineService{
+		repo:         repo,
+		terminalRepo: terminalRepo,
+		tx:           tx,
+		audit:        audit,
+	}
+}
+
+func (s *lineService) List(ctx context.Context) ([]model.Line, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *lineService) FindByID(ctx context.Context, id uuid.UUID) (*model.Line, error) {
+	line, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	line.Stops, err = s.repo.ListStops(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return line, nil
+}
+
+func (s *lineService) Create(ctx context.Context, actor *model.Actor, req *model.CreateLineRequest) (*model.Line, error) {
+	if err := s.validateStops(ctx, req.TerminalIDs); err != nil {
+		return nil, err
+	}
+
+	line := &model.Line{
+		ID:        uuid.New(),
+		Code:      req.Code,
+		Name:      req.Name,
+		Color:     req.Color,
+		IsActive:  true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	var created *model.Line
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, line, req.TerminalIDs); err != nil {
+			return err
+		}
+
+		var err error
+		if created, err = s.FindByID(ctx, line.ID); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, actor, AuditActionCreate, AuditEntityLine, line.ID.String(), nil, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *lineService) Update(ctx context.Context, actor *model.Actor, id uuid.UUID, req *model.UpdateLineRequest) (*model.Line, error) {
+	line, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *line
+
+	line.Name = req.Name
+	line.Color = req.Color
+	line.IsActive = *req.IsActive
+	line.UpdatedAt = time.Now()
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, line); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionUpdate, AuditEntityLine, id.String(), before, line)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.FindByID(ctx, id)
+}
+
+func (s *lineService) ReplaceStops(ctx context.Context, actor *model.Actor, id uuid.UUID, req *model.ReplaceLineStopsRequest) (*model.Line, error) {
+	before, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateStops(ctx, req.TerminalIDs); err != nil {
+		return nil, err
+	}
+
+	var line *model.Line
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.ReplaceStops(ctx, id, req.TerminalIDs); err != nil {
+			return err
+		}
+
+		var err error
+		if line, err = s.FindByID(ctx, id); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, actor, AuditActionUpdate, AuditEntityLine, id.String(), before, line)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return line, nil
+}
+
+func (s *lineService) Delete(ctx context.Context, actor *model.Actor, id uuid.UUID) error {
+	line, err := s.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionDelete, AuditEntityLine, id.String(), line, nil)
+	})
+}
+
+// validateStops rejects repeated terminals and terminals that do not exist or
+// have been deleted, which the database would otherwise report as a generic
+// constraint violation.
+func (s *lineService) validateStops(ctx context.Context, terminalIDs []uuid.UUID) error {
+	seen := make(map[uuid.UUID]bool, len(terminalIDs))
+
+	for _, terminalID := range terminalIDs {
+		if seen[terminalID] {
+			return ErrDuplicateLineStop.WithDetails(map[string]string{"terminal_id": terminalID.String()})
+		}
+		seen[terminalID] = true
+
+		_, err := s.terminalRepo.FindByID(ctx, terminalID, false)
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUnknownStop.WithDetails(map[string]string{"terminal_id": terminalID.String()})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/auth"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRefreshToken = apperror.Unauthorized("invalid refresh token")
+	ErrRefreshTokenReused  = apperror.Unauthorized("refresh token reuse detected")
+)
+
+type RefreshTokenService interface {
+	Issue(ctx context.Context, admin *model.Admin) (string, error)
+	Rotate(ctx context.Context, tokenString string) (*model.Admin, string, error)
+	Revoke(ctx context.Context, tokenString string) error
+}
+
+type refreshTokenService struct {
+	repo       repository.RefreshTokenRepository
+	adminRepo  repository.AdminRepository
+	jwtService auth.JWTService
+}
+
+func NewRefreshTokenService(repo repository.RefreshTokenRepository, adminRepo repository.AdminRepository, jwtService auth.JWTService) RefreshTokenService {
+	return &refreshTokenService{
+		repo:       repo,
+		adminRepo:  adminRepo,
+		jwtService: jwtService,
+	}
+}
+
+func (s *refreshTokenService) Issue(ctx context.Context, admin *model.Admin) (string, error) {
+	token := newRefreshToken(admin.ID, uuid.New())
+
+	if err := s.repo.Create(ctx, token); err != nil {
+		return "", err
+	}
+
+	return s.jwtService.GenerateRefreshToken(admin.Username, token.ID.String())
+}
+
+func (s *refreshTokenService) Rotate(ctx context.Context, tokenString string) (*model.Admin, string, error) {
+	current, err := s.lookup(ctx, tokenString)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// A revoked token being presented again means it leaked, so the whole
+	// chain issued from the same login is no longer trustworthy.
+	if current.RevokedAt != nil {
+		if err := s.repo.RevokeFamily(ctx, current.FamilyID); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	admin, err := s.adminRepo.FindByID(ctx, current.AdminID)
+	if err != nil {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	next := newRefreshToken(admin.ID, current.FamilyID)
+
+	err = s.repo.Rotate(ctx, current.ID, next)
+	if errors.Is(err, repository.ErrRefreshTokenRevoked) {
+		if err := s.repo.RevokeFamily(ctx, current.FamilyID); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	refreshToken, err := s.jwtService.GenerateRefreshToken(admin.Username, next.ID.String())
+	if err != nil {
+		return nil, "", err
+	}
+
+	return admin, refreshToken, nil
+}
+
+func (s *refreshTokenService) Revoke(ctx context.Context, tokenString string) error {
+	current, err := s.lookup(ctx, tokenString)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.RevokeFamily(ctx, current.FamilyID)
+}
+
+func (s *refreshTokenService) lookup(ctx context.Context, tokenString string) (*model.RefreshToken, error) {
+	claims, err := s.jwtService.VerifyToken(tokenString)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	id, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	token, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return token, nil
+}
+
+func newRefreshToken(adminID int, familyID uuid.UUID) *model.RefreshToken {
+	now := time.Now()
+
+	return &model.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  familyID,
+		AdminID:   adminID,
+		ExpiresAt: now.Add(auth.RefreshTokenTTL),
+		CreatedAt: now,
+	}
+}
@@ -4,47 +4,82 @@ import (
 	"context"
 	"time"
 
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
 	"github.com/google/uuid"
 )
 
+var ErrTerminalNotDeleted = apperror.Conflict("terminal is not deleted")
+
 type TerminalService interface {
-	List(ctx context.Context) ([]model.Terminal, error)
-	FindByID(ctx context.Context, id uuid.UUID) (*model.Terminal, error)
-	Create(ctx context.Context, req *model.CreateTerminalRequest) (*model.Terminal, error)
-	Update(ctx context.Context, id uuid.UUID, req *model.UpdateTerminalRequest) (*model.Terminal, error)
-	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, filter *model.TerminalFilter) ([]model.Terminal, int, error)
+	FindByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*model.Terminal, error)
+	Nearby(ctx context.Context, filter *model.NearbyTerminalFilter) ([]model.NearbyTerminal, error)
+	Create(ctx context.Context, actor *model.Actor, req *model.CreateTerminalRequest) (*model.Terminal, error)
+	Update(ctx context.Context, actor *model.Actor, id uuid.UUID, req *model.UpdateTerminalRequest, version *int) (*model.Terminal, error)
+	Delete(ctx context.Context, actor *model.Actor, id uuid.UUID) error
+	Restore(ctx context.Context, actor *model.Actor, id uuid.UUID) (*model.Terminal, error)
 }
 
 type terminalService struct {
-	repo repository.TerminalRepository
+	repo     repository.TerminalRepository
+	lineRepo repository.LineRepository
+	tx       repository.Transactor
+	audit    AuditService
 }
 
-func NewTerminalService(repo repository.TerminalRepository) TerminalService {
-	return &terminalService{repo: repo}
+func NewTerminalService(repo repository.TerminalRepository, lineRepo repository.LineRepository, tx repository.Transactor, audit AuditService) TerminalService {
+	return &terminalService{
+		repo:     repo,
+		lineRepo: lineRepo,
+		tx:       tx,
+		audit:    audit,
+	}
 }
 
-func (s *terminalService) List(ctx context.Context) ([]model.Terminal, error) {
-	return s.repo.List(ctx)
+func (s *terminalService) List(ctx context.Context, filter *model.TerminalFilter) ([]model.Terminal, int, error) {
+	return s.repo.List(ctx, filter)
+}
+
+func (s *terminalService) FindByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*model.Terminal, error) {
+	terminal, err := s.repo.FindByID(ctx, id, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	terminal.Lines, err = s.lineRepo.ListByTerminal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return terminal, nil
 }
 
-func (s *terminalService) FindByID(ctx context.Context, id uuid.UUID) (*model.Terminal, error) {
-	return s.repo.FindByID(ctx, id)
+func (s *terminalService) Nearby(ctx context.Context, filter *model.NearbyTerminalFilter) ([]model.NearbyTerminal, error) {
+	return s.repo.Nearby(ctx, filter)
 }
 
-func (s *terminalService) Create(ctx context.Context, req *model.CreateTerminalRequest) (*model.Terminal, error) {
+func (s *terminalService) Create(ctx context.Context, actor *model.Actor, req *model.CreateTerminalRequest) (*model.Terminal, error) {
 	terminal := &model.Terminal{
 		ID:        uuid.New(),
 		Code:      req.Code,
 		Name:      req.Name,
 		Address:   req.Address,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		FareZone:  req.FareZone,
 		IsActive:  true,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	err := s.repo.Create(ctx, terminal)
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, terminal); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionCreate, AuditEntityTerminal, terminal.ID.String(), nil, terminal)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -52,18 +87,34 @@ func (s *terminalService) Create(ctx context.Context, req *model.CreateTerminalR
 	return terminal, nil
 }
 
-func (s *terminalService) Update(ctx context.Context, id uuid.UUID, req *model.UpdateTerminalRequest) (*model.Terminal, error) {
-	terminal, err := s.repo.FindByID(ctx, id)
+// Update replaces the mutable terminal fields. When version is set the update
+// only succeeds if the terminal is still at that version.
+func (s *terminalService) Update(ctx context.Context, actor *model.Actor, id uuid.UUID, req *model.UpdateTerminalRequest, version *int) (*model.Terminal, error) {
+	terminal, err := s.repo.FindByID(ctx, id, false)
 	if err != nil {
 		return nil, err
 	}
 
+	if version != nil && *version != terminal.Version {
+		return nil, repository.ErrStale
+	}
+
+	before := *terminal
+
 	terminal.Name = req.Name
 	terminal.Address = req.Address
-	terminal.IsActive = req.IsActive
+	terminal.Latitude = req.Latitude
+	terminal.Longitude = req.Longitude
+	terminal.FareZone = req.FareZone
+	terminal.IsActive = *req.IsActive
 	terminal.UpdatedAt = time.Now()
 
-	err = s.repo.Update(ctx, terminal)
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, terminal); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionUpdate, AuditEntityTerminal, terminal.ID.String(), before, terminal)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +122,53 @@ func (s *terminalService) Update(ctx context.Context, id uuid.UUID, req *model.U
 	return terminal, nil
 }
 
-func (s *terminalService) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+func (s *terminalService) Delete(ctx context.Context, actor *model.Actor, id uuid.UUID) error {
+	terminal, err := s.repo.FindByID(ctx, id, false)
+	if err != nil {
+		return err
+	}
+
+	before := *terminal
+
+	now := time.Now()
+	terminal.DeletedAt = &now
+	terminal.UpdatedAt = now
+	terminal.Version++
+
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SoftDelete(ctx, id, now); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionDelete, AuditEntityTerminal, id.String(), before, terminal)
+	})
+}
+
+func (s *terminalService) Restore(ctx context.Context, actor *model.Actor, id uuid.UUID) (*model.Terminal, error) {
+	terminal, err := s.repo.FindByID(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if terminal.DeletedAt == nil {
+		return nil, ErrTerminalNotDeleted
+	}
+
+	before := *terminal
+
+	now := time.Now()
+	terminal.DeletedAt = nil
+	terminal.UpdatedAt = now
+	terminal.Version++
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Restore(ctx, id, now); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionRestore, AuditEntityTerminal, id.String(), before, terminal)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return terminal, nil
 }
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	ClosedReasonInactive     = "inactive"
+	ClosedReasonHoliday      = "holiday"
+	ClosedReasonOutsideHours = "outside_hours"
+)
+
+const (
+	serviceDateLayout = "2006-01-02"
+	serviceTimeLayout = "15:04"
+)
+
+var (
+	ErrDuplicateServiceDay = apperror.BadRequest("each day of the week can only have one opening window")
+	ErrTerminalClosed      = apperror.Forbidden("terminal is closed for tap-in")
+)
+
+type TerminalScheduleService interface {
+	Get(ctx context.Context, terminalID uuid.UUID) (*model.TerminalServiceSchedule, error)
+	ReplaceHours(ctx context.Context, actor *model.Actor, terminalID uuid.UUID, req *model.ReplaceServiceHoursRequest) (*model.TerminalServiceSchedule, error)
+	UpsertException(ctx context.Context, actor *model.Actor, terminalID uuid.UUID, date time.Time, req *model.UpsertServiceExceptionRequest) (*model.ServiceException, error)
+	DeleteException(ctx context.Context, actor *model.Actor, terminalID uuid.UUID, date time.Time) error
+	Status(ctx context.Context, terminalID uuid.UUID, at time.Time) (*model.TerminalOpenStatus, error)
+	CheckTapIn(ctx context.Context, terminalID uuid.UUID, at time.Time) error
+}
+
+type terminalScheduleService struct {
+	repo         repository.TerminalScheduleRepository
+	terminalRepo repository.TerminalRepository
+	tx           repository.Transactor
+	audit        AuditService
+	location     *time.Location
+	grace        time.Duration
+}
+
+func NewTerminalScheduleService(repo repository.TerminalScheduleRepository, terminalRepo repository.TerminalRepository, tx repository.Transactor, audit AuditService, cfg *config.Config) TerminalScheduleService {
+	return &terminalScheduleService{
+		repo:         repo,
+		terminalRepo: terminalRepo,
+		tx:           tx,
+		audit:        audit,
+		location:     cfg.ServiceLocation,
+		grace:        cfg.TapInGracePeriod,
+	}
+}
+
+// Get returns the weekly hours together with today's and upcoming
+// exceptions.
+func (s *terminalScheduleService) Get(ctx context.Context, terminalID uuid.UUID) (*model.TerminalServiceSchedule, error) {
+	if _, err := s.terminalRepo.FindByID(ctx, terminalID, false); err != nil {
+		return nil, err
+	}
+
+	return s.schedule(ctx, terminalID)
+}
+
+func (s *terminalScheduleService) ReplaceHours(ctx context.Context, actor *model.Actor, terminalID uuid.UUID, req *model.ReplaceServiceHoursRequest) (*model.TerminalServiceSchedule, error) {
+	if _, err := s.terminalRepo.FindByID(ctx, terminalID, false); err != nil {
+		return nil, err
+	}
+
+	before, err := s.repo.ListHours(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool, len(req.Hours))
+	hours := make([]model.ServiceHours, 0, len(req.Hours))
+
+	for _, h := range req.Hours {
+		if seen[*h.DayOfWeek] {
+			return nil, ErrDuplicateServiceDay.WithDetails(map[string]int{"day_of_week": *h.DayOfWeek})
+		}
+		seen[*h.DayOfWeek] = true
+
+		hours = append(hours, model.ServiceHours{
+			DayOfWeek: *h.DayOfWeek,
+			OpensAt:   h.OpensAt,
+			ClosesAt:  h.ClosesAt,
+		})
+	}
+
+	var schedule *model.TerminalServiceSchedule
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.ReplaceHours(ctx, terminalID, hours); err != nil {
+			return err
+		}
+
+		var err error
+		if schedule, err = s.schedule(ctx, terminalID); err != nil {
+			return err
+		}
+
+		return s.audit.Record(ctx, actor, AuditActionUpdate, AuditEntityServiceHours, terminalID.String(), before, schedule.Hours)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (s *terminalScheduleService) UpsertException(ctx context.Context, actor *model.Actor, terminalID uuid.UUID, date time.Time, req *model.UpsertServiceExceptionRequest) (*model.ServiceException, error) {
+	if _, err := s.terminalRepo.FindByID(ctx, terminalID, false); err != nil {
+		return nil, err
+	}
+
+	day := date.Format(serviceDateLayout)
+
+	existing, err := s.repo.ListExceptions(ctx, terminalID, &day, &day)
+	if err != nil {
+		return nil, err
+	}
+
+	exception := &model.ServiceException{
+		Date:     day,
+		OpensAt:  req.OpensAt,
+		ClosesAt: req.ClosesAt,
+		Note:     req.Note,
+	}
+
+	action := AuditActionCreate
+	var before any
+	if len(existing) > 0 {
+		action = AuditActionUpdate
+		before = existing[0]
+	}
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpsertException(ctx, terminalID, exception); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, action, AuditEntityServiceException, terminalID.String(), before, exception)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return exception, nil
+}
+
+func (s *terminalScheduleService) DeleteException(ctx context.Context, actor *model.Actor, terminalID uuid.UUID, date time.Time) error {
+	day := date.Format(serviceDateLayout)
+
+	existing, err := s.repo.ListExceptions(ctx, terminalID, &day, &day)
+	if err != nil {
+		return err
+	}
+
+	if len(existing) == 0 {
+		return fmt.Errorf("service exception on %s: %w", day, repository.ErrNotFound)
+	}
+
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteException(ctx, terminalID, day); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionDelete, AuditEntityServiceException, terminalID.String(), existing[0], nil)
+	})
+}
+
+// Status reports whether the terminal is open at the given instant. Open
+// follows the published hours exactly, TapInAllowed widens every window by
+// the configured grace period on both sides.
+func (s *terminalScheduleService) Status(ctx context.Context, terminalID uuid.UUID, at time.Time) (*model.TerminalOpenStatus, error) {
+	terminal, err := s.terminalRepo.FindByID(ctx, terminalID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	local := at.In(s.location)
+	status := &model.TerminalOpenStatus{TerminalID: terminalID, At: local}
+
+	if !terminal.IsActive {
+		status.Reason = ClosedReasonInactive
+		return status, nil
+	}
+
+	hours, err := s.repo.ListHours(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Windows from yesterday can run past midnight and tomorrow's window can
+	// start within the grace period, so all three days are considered.
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.location)
+	from := today.AddDate(0, 0, -1).Format(serviceDateLayout)
+	to := today.AddDate(0, 0, 1).Format(serviceDateLayout)
+
+	exceptions, err := s.repo.ListExceptions(ctx, terminalID, &from, &to)
+	if err != nil {
+		return nil, err
+	}
+
+	byDate := make(map[string]model.ServiceException, len(exceptions))
+	for _, e := range exceptions {
+		byDate[e.Date] = e
+	}
+
+	var next *serviceWindow
+	for offset := -1; offset <= 1; offset++ {
+		window, ok := dayWindow(today.AddDate(0, 0, offset), hours, byDate)
+		if !ok {
+			continue
+		}
+
+		if !local.Before(window.opens) && local.Before(window.closes) {
+			status.Open = true
+			status.OpensAt, status.ClosesAt = &window.opens, &window.closes
+		}
+
+		if !local.Before(window.opens.Add(-s.grace)) && local.Before(window.closes.Add(s.grace)) {
+			status.TapInAllowed = true
+		}
+
+		if next == nil && window.opens.After(local) {
+			next = &window
+		}
+	}
+
+	if status.Open {
+		return status, nil
+	}
+
+	status.Reason = ClosedReasonOutsideHours
+	if e, ok := byDate[today.Format(serviceDateLayout)]; ok && e.OpensAt == nil {
+		status.Reason = ClosedReasonHoliday
+	}
+
+	if next != nil {
+		status.OpensAt, status.ClosesAt = &next.opens, &next.closes
+	}
+
+	return status, nil
+}
+
+// CheckTapIn rejects a tap-in at a terminal that is closed, allowing for the
+// configured grace period around opening and closing time.
+func (s *terminalScheduleService) CheckTapIn(ctx context.Context, terminalID uuid.UUID, at time.Time) error {
+	status, err := s.Status(ctx, terminalID, at)
+	if err != nil {
+		return err
+	}
+
+	if !status.TapInAllowed {
+		return ErrTerminalClosed.WithDetails(map[string]string{"reason": status.Reason})
+	}
+
+	return nil
+}
+
+func (s *terminalScheduleService) schedule(ctx context.Context, terminalID uuid.UUID) (*model.TerminalServiceSchedule, error) {
+	hours, err := s.repo.ListHours(ctx, terminalID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Now().In(s.location).Format(serviceDateLayout)
+
+	exceptions, err := s.repo.ListExceptions(ctx, terminalID, &today, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TerminalServiceSchedule{Hours: hours, Exceptions: exceptions}, nil
+}
+
+type serviceWindow struct {
+	opens  time.Time
+	closes time.Time
+}
+
+// dayWindow resolves the opening window of a single day. An exception for
+// the date wins over the weekly hours, and a terminal without any weekly
+// hours is open around the clock.
+func dayWindow(day time.Time, hours []model.ServiceHours, exceptions map[string]model.ServiceException) (serviceWindow, bool) {
+	if e, ok := exceptions[day.Format(serviceDateLayout)]; ok {
+		if e.OpensAt == nil || e.ClosesAt == nil {
+			return serviceWindow{}, false
+		}
+		return windowAt(day, *e.OpensAt, *e.ClosesAt)
+	}
+
+	if len(hours) == 0 {
+		return serviceWindow{opens: day, closes: day.AddDate(0, 0, 1)}, true
+	}
+
+	for _, h := range hours {
+		if h.DayOfWeek == int(day.Weekday()) {
+			return windowAt(day, h.OpensAt, h.ClosesAt)
+		}
+	}
+
+	return serviceWindow{}, false
+}
+
+// windowAt builds the window for day from HH:MM clock times. A closing time
+// at or before the opening time belongs to the following day.
+func windowAt(day time.Time, opensAt, closesAt string) (serviceWindow, bool) {
+	opens, err := time.Parse(serviceTimeLayout, opensAt)
+	if err != nil {
+		return serviceWindow{}, false
+	}
+	closes, err := time.Parse(serviceTimeLayout, closesAt)
+	if err != nil {
+		return serviceWindow{}, false
+	}
+
+	window := serviceWindow{
+		opens:  time.Date(day.Year(), day.Month(), day.Day(), opens.Hour(), opens.Minute(), 0, 0, day.Location()),
+		closes: time.Date(day.Year(), day.Month(), day.Day(), closes.Hour(), closes.Minute(), 0, 0, day.Location()),
+	}
+	if !window.closes.After(window.opens) {
+		window.closes = window.closes.AddDate(0, 0, 1)
+	}
+
+	return window, true
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+type fakeTerminalScheduleRepository struct {
+	repository.TerminalScheduleRepository
+
+	hours      []model.ServiceHours
+	exceptions []model.ServiceException
+}
+
+func (r *fakeTerminalScheduleRepository) ListHours(ctx context.Context, terminalID uuid.UUID) ([]model.ServiceHours, error) {
+	return r.hours, nil
+}
+
+func (r *fakeTerminalScheduleRepository) ListExceptions(ctx context.Context, terminalID uuid.UUID, from, to *string) ([]model.ServiceException, error) {
+	var exceptions []model.ServiceException
+	for _, e := range r.exceptions {
+		if (from == nil || e.Date >= *from) && (to == nil || e.Date <= *to) {
+			exceptions = append(exceptions, e)
+		}
+	}
+	return exceptions, nil
+}
+
+func TestWindowAt(t *testing.T) {
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		opensAt    string
+		closesAt   string
+		wantOpens  time.Time
+		wantCloses time.Time
+		wantOK     bool
+	}{
+		{
+			name:    "same day",
+			opensAt: "05:00", closesAt: "22:30",
+			wantOpens:  time.Date(2026, 3, 2, 5, 0, 0, 0, time.UTC),
+			wantCloses: time.Date(2026, 3, 2, 22, 30, 0, 0, time.UTC),
+			wantOK:     true,
+		},
+		{
+			name:    "past midnight",
+			opensAt: "18:00", closesAt: "02:00",
+			wantOpens:  time.Date(2026, 3, 2, 18, 0, 0, 0, time.UTC),
+			wantCloses: time.Date(2026, 3, 3, 2, 0, 0, 0, time.UTC),
+			wantOK:     true,
+		},
+		{
+			name:    "closing equals opening spans a full day",
+			opensAt: "04:00", closesAt: "04:00",
+			wantOpens:  time.Date(2026, 3, 2, 4, 0, 0, 0, time.UTC),
+			wantCloses: time.Date(2026, 3, 3, 4, 0, 0, 0, time.UTC),
+			wantOK:     true,
+		},
+		{name: "invalid opening", opensAt: "5am", closesAt: "22:00"},
+		{name: "invalid closing", opensAt: "05:00", closesAt: "24:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, ok := windowAt(day, tt.opensAt, tt.closesAt)
+			if ok != tt.wantOK {
+				t.Fatalf("windowAt() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if !window.opens.Equal(tt.wantOpens) || !window.closes.Equal(tt.wantCloses) {
+				t.Errorf("windowAt() = [%v, %v), want [%v, %v)", window.opens, window.closes, tt.wantOpens, tt.wantCloses)
+			}
+		})
+	}
+}
+
+func TestDayWindow(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, jakarta)
+
+	weekdays := []model.ServiceHours{
+		{DayOfWeek: int(time.Monday), OpensAt: "05:00", ClosesAt: "22:00"},
+		{DayOfWeek: int(time.Tuesday), OpensAt: "05:00", ClosesAt: "22:00"},
+	}
+
+	opensAt, closesAt := "08:00", "12:00"
+
+	tests := []struct {
+		name       string
+		day        time.Time
+		hours      []model.ServiceHours
+		exceptions map[string]model.ServiceException
+		wantOpens  time.Time
+		wantCloses time.Time
+		wantOK     bool
+	}{
+		{
+			name:       "weekly hours",
+			day:        monday,
+			hours:      weekdays,
+			wantOpens:  time.Date(2026, 3, 2, 5, 0, 0, 0, jakarta),
+			wantCloses: time.Date(2026, 3, 2, 22, 0, 0, 0, jakarta),
+			wantOK:     true,
+		},
+		{
+			name:  "no hours for the weekday",
+			day:   monday.AddDate(0, 0, 5),
+			hours: weekdays,
+		},
+		{
+			name:       "no weekly hours is open around the clock",
+			day:        monday,
+			wantOpens:  monday,
+			wantCloses: monday.AddDate(0, 0, 1),
+			wantOK:     true,
+		},
+		{
+			name:  "holiday",
+			day:   monday,
+			hours: weekdays,
+			exceptions: map[string]model.ServiceException{
+				"2026-03-02": {Date: "2026-03-02"},
+			},
+		},
+		{
+			name:  "special hours win over weekly hours",
+			day:   monday,
+			hours: weekdays,
+			exceptions: map[string]model.ServiceException{
+				"2026-03-02": {Date: "2026-03-02", OpensAt: &opensAt, ClosesAt: &closesAt},
+			},
+			wantOpens:  time.Date(2026, 3, 2, 8, 0, 0, 0, jakarta),
+			wantCloses: time.Date(2026, 3, 2, 12, 0, 0, 0, jakarta),
+			wantOK:     true,
+		},
+		{
+			name: "exception on another day is ignored",
+			day:  monday,
+			exceptions: map[string]model.ServiceException{
+				"2026-03-03": {Date: "2026-03-03"},
+			},
+			wantOpens:  monday,
+			wantCloses: monday.AddDate(0, 0, 1),
+			wantOK:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, ok := dayWindow(tt.day, tt.hours, tt.exceptions)
+			if ok != tt.wantOK {
+				t.Fatalf("dayWindow() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if !window.opens.Equal(tt.wantOpens) || !window.closes.Equal(tt.wantCloses) {
+				t.Errorf("dayWindow() = [%v, %v), want [%v, %v)", window.opens, window.closes, tt.wantOpens, tt.wantCloses)
+			}
+		})
+	}
+}
+
+func TestCheckTapIn(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 3, day, hour, minute, 0, 0, jakarta) }
+
+	open := uuid.MustParse("00000000-0000-0000-0000-0000000000a1")
+	inactive := uuid.MustParse("00000000-0000-0000-0000-0000000000a2")
+	unknown := uuid.MustParse("00000000-0000-0000-0000-0000000000a3")
+
+	terminals := &fakeTerminalRepository{terminals: map[uuid.UUID]*model.Terminal{
+		open:     {ID: open, IsActive: true},
+		inactive: {ID: inactive, IsActive: false},
+	}}
+
+	// Monday to Wednesday 05:00-22:00, with Tuesday 3 March a holiday.
+	schedule := &fakeTerminalScheduleRepository{
+		hours: []model.ServiceHours{
+			{DayOfWeek: int(time.Monday), OpensAt: "05:00", ClosesAt: "22:00"},
+			{DayOfWeek: int(time.Tuesday), OpensAt: "05:00", ClosesAt: "22:00"},
+			{DayOfWeek: int(time.Wednesday), OpensAt: "05:00", ClosesAt: "22:00"},
+		},
+		exceptions: []model.ServiceException{{Date: "2026-03-03"}},
+	}
+
+	s := NewTerminalScheduleService(schedule, terminals, fakeTransactor{}, fakeAuditService{}, &config.Config{
+		ServiceLocation:  jakarta,
+		TapInGracePeriod: 15 * time.Minute,
+	})
+
+	tests := []struct {
+		name       string
+		terminalID uuid.UUID
+		at         time.Time
+		wantErr    error
+		wantReason string
+	}{
+		{name: "within hours", terminalID: open, at: at(2, 12, 0)},
+		{name: "grace before opening", terminalID: open, at: at(2, 4, 50)},
+		{name: "grace after closing", terminalID: open, at: at(2, 22, 10)},
+		{name: "before the grace period", terminalID: open, at: at(2, 4, 40), wantErr: ErrTerminalClosed, wantReason: ClosedReasonOutsideHours},
+		{name: "after the grace period", terminalID: open, at: at(2, 22, 20), wantErr: ErrTerminalClosed, wantReason: ClosedReasonOutsideHours},
+		{name: "holiday", terminalID: open, at: at(3, 12, 0), wantErr: ErrTerminalClosed, wantReason: ClosedReasonHoliday},
+		{name: "inactive terminal", terminalID: inactive, at: at(2, 12, 0), wantErr: ErrTerminalClosed, wantReason: ClosedReasonInactive},
+		{name: "unknown terminal", terminalID: unknown, at: at(2, 12, 0), wantErr: repository.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.CheckTapIn(context.Background(), tt.terminalID, tt.at)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckTapIn() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantReason == "" {
+				return
+			}
+
+			var appErr *apperror.Error
+			if !errors.As(err, &appErr) {
+				t.Fatalf("CheckTapIn() error = %T, want *apperror.Error", err)
+			}
+			if details, _ := appErr.Details.(map[string]string); details["reason"] != tt.wantReason {
+				t.Errorf("reason = %v, want %s", appErr.Details, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/auth"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/model"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/repository"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	ErrTOTPAlreadyEnabled = apperror.Conflict("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled    = apperror.Conflict("two-factor authentication is not enrolled")
+	ErrInvalidTOTPCode    = apperror.BadRequest("invalid two-factor code")
+)
+
+type TOTPService interface {
+	Enroll(ctx context.Context, username string) (*model.TOTPEnrollmentResponse, error)
+	Confirm(ctx context.Context, actor *model.Actor, code string) ([]string, error)
+	Disable(ctx context.Context, actor *model.Actor, code string) error
+	Verify(ctx context.Context, username, code, ip string) (*model.Admin, error)
+}
+
+type totpService struct {
+	adminRepo    repository.AdminRepository
+	recoveryRepo repository.RecoveryCodeRepository
+	attemptRepo  repository.LoginAttemptRepository
+	tx           repository.Transactor
+	audit        AuditService
+	cfg          *config.Config
+}
+
+func NewTOTPService(adminRepo repository.AdminRepository, recoveryRepo repository.RecoveryCodeRepository, attemptRepo repository.LoginAttemptRepository, tx repository.Transactor, audit AuditService, cfg *config.Config) TOTPService {
+	return &totpService{
+		adminRepo:    adminRepo,
+		recoveryRepo: recoveryRepo,
+		attemptRepo:  attemptRepo,
+		tx:           tx,
+		audit:        audit,
+		cfg:          cfg,
+	}
+}
+
+func (s *totpService) Enroll(ctx context.Context, username string) (*model.TOTPEnrollmentResponse, error) {
+	admin, err := s.adminRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if admin.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.adminRepo.UpdateTOTP(ctx, admin.ID, &secret, false); err != nil {
+		return nil, err
+	}
+
+	return &model.TOTPEnrollmentResponse{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(s.cfg.TOTPIssuer, admin.Username, secret),
+	}, nil
+}
+
+func (s *totpService) Confirm(ctx context.Context, actor *model.Actor, code string) ([]string, error) {
+	admin, err := s.adminRepo.FindByUsername(ctx, actor.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	if admin.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if admin.TOTPSecret == nil {
+		return nil, ErrTOTPNotEnrolled
+	}
+
+	step, ok := auth.ValidateTOTP(*admin.TOTPSecret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidTOTPCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.adminRepo.EnableTOTP(ctx, admin.ID, *admin.TOTPSecret, step, hashes); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionEnableTOTP, AuditEntityAdmin, strconv.Itoa(admin.ID), nil, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *totpService) Disable(ctx context.Context, actor *model.Actor, code string) error {
+	admin, err := s.adminRepo.FindByUsername(ctx, actor.Username)
+	if err != nil {
+		return err
+	}
+
+	if !admin.TOTPEnabled {
+		return ErrTOTPNotEnrolled
+	}
+
+	ok, err := s.checkCode(ctx, admin, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTOTPCode
+	}
+
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.adminRepo.DisableTOTP(ctx, admin.ID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, actor, AuditActionDisableTOTP, AuditEntityAdmin, strconv.Itoa(admin.ID), nil, nil)
+	})
+}
+
+func (s *totpService) Verify(ctx context.Context, username, code, ip string) (*model.Admin, error) {
+	window := s.cfg.LoginLockoutDuration
+
+	failures, err := s.attemptRepo.CountByUsername(ctx, username, window)
+	if err != nil {
+		return nil, err
+	}
+	if failures >= s.cfg.LoginMaxAttempts {
+		return nil, ErrAccountLocked
+	}
+
+	admin, err := s.adminRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, ErrInvalidTOTPCode
+	}
+
+	if !admin.TOTPEnabled {
+		return nil, ErrTOTPNotEnrolled
+	}
+
+	ok, err := s.checkCode(ctx, admin, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.attemptRepo.Record(ctx, username, ip); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidTOTPCode
+	}
+
+	if err := s.attemptRepo.ClearByUsername(ctx, username); err != nil {
+		return nil, err
+	}
+
+	return admin, nil
+}
+
+// checkCode accepts either a current TOTP code or an unused recovery code.
+func (s *totpService) checkCode(ctx context.Context, admin *model.Admin, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if step, ok := auth.ValidateTOTP(*admin.TOTPSecret, code, time.Now()); ok {
+		return s.adminRepo.AdvanceTOTPStep(ctx, admin.ID, step)
+	}
+
+	return s.recoveryRepo.Consume(ctx, admin.ID, hashRecoveryCode(code))
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		encoded := strings.ToLower(encoding.EncodeToString(raw))
+		codes[i] = encoded[:4] + "-" + encoded[4:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(code, "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
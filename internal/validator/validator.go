@@ -1,9 +1,12 @@
 package validator
 
 import (
+	"errors"
 	"fmt"
-	"net/http"
+	"reflect"
+	"strings"
 
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -11,34 +14,71 @@ var validate *validator.Validate
 
 func init() {
 	validate = validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
 }
 
+// ValidateStruct validates s and returns a validation *apperror.Error whose
+// details map each offending field to a human readable message.
 func ValidateStruct(s any) error {
-	return validate.Struct(s)
-}
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
 
-func HandleValidationError(w http.ResponseWriter, err error) {
-	if validationErrors, ok := err.(validator.ValidationErrors); ok {
-		errorMessages := make(map[string]string)
-		for _, fieldError := range validationErrors {
-			fieldName := fieldError.Field()
-			switch fieldError.Tag() {
-			case "required":
-				errorMessages[fieldName] = fmt.Sprintf("%s is required", fieldName)
-			case "min":
-				errorMessages[fieldName] = fmt.Sprintf("%s must be at least %s characters", fieldName, fieldError.Param())
-			case "max":
-				errorMessages[fieldName] = fmt.Sprintf("%s must be at most %s characters", fieldName, fieldError.Param())
-			default:
-				errorMessages[fieldName] = fmt.Sprintf("%s is invalid", fieldName)
-			}
-		}
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return apperror.BadRequest("invalid request")
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, `{"errors": %v}`, errorMessages)
-		return
+	details := make(map[string]string, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		details[fieldError.Field()] = fieldMessage(fieldError)
 	}
 
-	http.Error(w, "Invalid request", http.StatusBadRequest)
+	return apperror.Validation("request validation failed", details)
+}
+
+func fieldMessage(fieldError validator.FieldError) string {
+	fieldName := fieldError.Field()
+	unit := ""
+	if fieldError.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch fieldError.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", fieldName)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s%s", fieldName, fieldError.Param(), unit)
+	case "max":
+		return fmt.Sprintf("%s must be at most %s%s", fieldName, fieldError.Param(), unit)
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", fieldName, fieldError.Param())
+	case "len":
+		return fmt.Sprintf("%s must be exactly %s%s", fieldName, fieldError.Param(), unit)
+	case "numeric":
+		return fmt.Sprintf("%s must contain digits only", fieldName)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", fieldName, fieldError.Param())
+	case "required_with":
+		return fmt.Sprintf("%s is required when %s is set", fieldName, strings.ToLower(fieldError.Param()))
+	case "datetime":
+		return fmt.Sprintf("%s must match the format %s", fieldName, fieldError.Param())
+	case "alphanum":
+		return fmt.Sprintf("%s must contain letters and digits only", fieldName)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", fieldName)
+	case "e164":
+		return fmt.Sprintf("%s must be a phone number in international format, e.g. +6281234567890", fieldName)
+	case "latitude", "longitude":
+		return fmt.Sprintf("%s must be a valid %s", fieldName, fieldError.Tag())
+	default:
+		return fmt.Sprintf("%s is invalid", fieldName)
+	}
 }
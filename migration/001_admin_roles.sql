@@ -0,0 +1,10 @@
+-- DBMS: PostgreSQL
+
+DROP TYPE IF EXISTS admin_role CASCADE;
+
+CREATE TYPE admin_role AS ENUM ('superadmin', 'operator', 'finance', 'support', 'viewer');
+
+ALTER TABLE admins ADD COLUMN role admin_role NOT NULL DEFAULT 'viewer';
+
+-- Promote default admin
+UPDATE admins SET role = 'superadmin' WHERE username = 'admin';
@@ -0,0 +1,13 @@
+-- DBMS: PostgreSQL
+
+DROP TABLE IF EXISTS login_attempts CASCADE;
+
+CREATE TABLE login_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    username VARCHAR(50) NOT NULL,
+    ip_address VARCHAR(45) NOT NULL,
+    attempted_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_attempts_username ON login_attempts(username, attempted_at);
+CREATE INDEX idx_login_attempts_ip ON login_attempts(ip_address, attempted_at);
@@ -0,0 +1,18 @@
+-- DBMS: PostgreSQL
+
+DROP TABLE IF EXISTS admin_recovery_codes CASCADE;
+
+ALTER TABLE admins ADD COLUMN totp_secret VARCHAR(64);
+ALTER TABLE admins ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE admins ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE admin_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    admin_id INTEGER NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_recovery_admin FOREIGN KEY (admin_id) REFERENCES admins(id) ON DELETE CASCADE,
+    CONSTRAINT unique_recovery_code UNIQUE (admin_id, code_hash)
+);
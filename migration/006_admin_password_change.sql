@@ -0,0 +1,6 @@
+-- DBMS: PostgreSQL
+
+ALTER TABLE admins ADD COLUMN must_change_password BOOLEAN NOT NULL DEFAULT false;
+
+-- Default admin ships with a well-known password
+UPDATE admins SET must_change_password = true WHERE username = 'admin';
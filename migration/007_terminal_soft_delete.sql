@@ -0,0 +1,9 @@
+-- DBMS: PostgreSQL
+
+DROP INDEX IF EXISTS idx_terminals_name_not_deleted;
+
+ALTER TABLE terminals ADD COLUMN deleted_at TIMESTAMP;
+
+-- Deleted terminals keep their code reserved so historical transactions and
+-- fares still resolve to a single terminal.
+CREATE INDEX idx_terminals_name_not_deleted ON terminals (name) WHERE deleted_at IS NULL;
@@ -0,0 +1,6 @@
+-- DBMS: PostgreSQL
+
+ALTER TABLE terminals DROP COLUMN IF EXISTS version;
+
+-- Incremented on every write; exposed as the ETag for optimistic concurrency.
+ALTER TABLE terminals ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,11 @@
+-- DBMS: PostgreSQL
+
+ALTER TABLE terminals DROP CONSTRAINT IF EXISTS chk_terminal_location;
+ALTER TABLE terminals DROP COLUMN IF EXISTS latitude;
+ALTER TABLE terminals DROP COLUMN IF EXISTS longitude;
+
+ALTER TABLE terminals ADD COLUMN latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90);
+ALTER TABLE terminals ADD COLUMN longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180);
+
+-- Coordinates are either both set or both empty
+ALTER TABLE terminals ADD CONSTRAINT chk_terminal_location CHECK ((latitude IS NULL) = (longitude IS NULL));
@@ -0,0 +1,16 @@
+-- DBMS: PostgreSQL
+
+DROP TABLE IF EXISTS route_distances CASCADE;
+
+-- Track distance between terminals, used by the fare generator in place of
+-- the straight-line distance between terminal coordinates.
+CREATE TABLE route_distances (
+    origin_terminal_id UUID NOT NULL,
+    destination_terminal_id UUID NOT NULL,
+    distance_km NUMERIC(8, 3) NOT NULL CHECK (distance_km > 0),
+
+    PRIMARY KEY (origin_terminal_id, destination_terminal_id),
+    CONSTRAINT fk_route_origin FOREIGN KEY (origin_terminal_id) REFERENCES terminals(id) ON DELETE CASCADE,
+    CONSTRAINT fk_route_destination FOREIGN KEY (destination_terminal_id) REFERENCES terminals(id) ON DELETE CASCADE,
+    CONSTRAINT chk_route_distinct CHECK (origin_terminal_id <> destination_terminal_id)
+);
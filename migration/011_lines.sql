@@ -0,0 +1,28 @@
+-- DBMS: PostgreSQL
+
+DROP TABLE IF EXISTS line_stops CASCADE;
+DROP TABLE IF EXISTS lines CASCADE;
+
+CREATE TABLE lines (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code VARCHAR(10) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    color VARCHAR(7),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Stops are ordered by sequence, starting at 1 for the first terminal
+CREATE TABLE line_stops (
+    line_id UUID NOT NULL,
+    terminal_id UUID NOT NULL,
+    sequence INTEGER NOT NULL CHECK (sequence > 0),
+
+    PRIMARY KEY (line_id, sequence),
+    CONSTRAINT fk_stop_line FOREIGN KEY (line_id) REFERENCES lines(id) ON DELETE CASCADE,
+    CONSTRAINT fk_stop_terminal FOREIGN KEY (terminal_id) REFERENCES terminals(id) ON DELETE RESTRICT,
+    CONSTRAINT unique_terminal_per_line UNIQUE (line_id, terminal_id)
+);
+
+CREATE INDEX idx_line_stops_terminal ON line_stops(terminal_id);
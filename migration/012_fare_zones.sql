@@ -0,0 +1,24 @@
+-- DBMS: PostgreSQL
+
+ALTER TABLE terminals DROP COLUMN IF EXISTS fare_zone;
+DROP TABLE IF EXISTS zone_fares CASCADE;
+DROP TABLE IF EXISTS fare_zones CASCADE;
+
+-- Zones are numbered outward so that the number of zones travelled between
+-- two terminals is the difference of their zone numbers plus one.
+CREATE TABLE fare_zones (
+    zone INTEGER PRIMARY KEY CHECK (zone > 0),
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE zone_fares (
+    zones_travelled INTEGER PRIMARY KEY CHECK (zones_travelled > 0),
+    fare_amount NUMERIC(8, 2) NOT NULL CHECK (fare_amount >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE terminals ADD COLUMN fare_zone INTEGER;
+ALTER TABLE terminals ADD CONSTRAINT fk_terminal_fare_zone FOREIGN KEY (fare_zone) REFERENCES fare_zones(zone) ON DELETE RESTRICT;
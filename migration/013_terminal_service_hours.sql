@@ -0,0 +1,31 @@
+-- DBMS: PostgreSQL
+
+DROP TABLE IF EXISTS terminal_service_exceptions CASCADE;
+DROP TABLE IF EXISTS terminal_service_hours CASCADE;
+
+-- Weekly opening hours, day_of_week follows Go's time.Weekday (0 = Sunday).
+-- A closing time before the opening time runs past midnight, equal times mean
+-- the terminal is open around the clock.
+CREATE TABLE terminal_service_hours (
+    terminal_id UUID NOT NULL,
+    day_of_week SMALLINT NOT NULL CHECK (day_of_week BETWEEN 0 AND 6),
+    opens_at TIME NOT NULL,
+    closes_at TIME NOT NULL,
+
+    PRIMARY KEY (terminal_id, day_of_week),
+    CONSTRAINT fk_service_hours_terminal FOREIGN KEY (terminal_id) REFERENCES terminals(id) ON DELETE CASCADE
+);
+
+-- Exceptions override the weekly hours for a single date. Without opening
+-- hours the terminal is closed for the whole day (e.g. a public holiday).
+CREATE TABLE terminal_service_exceptions (
+    terminal_id UUID NOT NULL,
+    service_date DATE NOT NULL,
+    opens_at TIME,
+    closes_at TIME,
+    note VARCHAR(200),
+
+    PRIMARY KEY (terminal_id, service_date),
+    CONSTRAINT fk_service_exception_terminal FOREIGN KEY (terminal_id) REFERENCES terminals(id) ON DELETE CASCADE,
+    CONSTRAINT chk_service_exception_hours CHECK ((opens_at IS NULL) = (closes_at IS NULL))
+);
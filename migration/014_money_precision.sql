@@ -0,0 +1,9 @@
+-- DBMS: PostgreSQL
+
+-- NUMERIC(8, 2) tops out at 999,999.99, which is too small for balances and
+-- fares in IDR. Two decimals are kept so currencies with minor units still
+-- fit; IDR amounts are stored as whole numbers.
+ALTER TABLE fare_matrix ALTER COLUMN fare_amount TYPE NUMERIC(15, 2);
+ALTER TABLE zone_fares ALTER COLUMN fare_amount TYPE NUMERIC(15, 2);
+ALTER TABLE cards ALTER COLUMN balance TYPE NUMERIC(15, 2);
+ALTER TABLE transactions ALTER COLUMN amount TYPE NUMERIC(15, 2);
@@ -0,0 +1,41 @@
+-- DBMS: PostgreSQL
+
+DROP TABLE IF EXISTS card_hotlist CASCADE;
+DROP FUNCTION IF EXISTS card_hotlist_record() CASCADE;
+
+-- Every status change of a card gets the next hotlist version. Gates keep the
+-- last version they applied and fetch the changes after it.
+CREATE TABLE card_hotlist (
+    version BIGSERIAL PRIMARY KEY,
+    card_id UUID NOT NULL,
+    card_number VARCHAR(16) NOT NULL,
+    status card_status NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_hotlist_card FOREIGN KEY (card_id) REFERENCES cards(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_card_hotlist_card ON card_hotlist(card_id);
+
+CREATE FUNCTION card_hotlist_record() RETURNS TRIGGER AS $$
+BEGIN
+    -- Serialises writers until commit so versions become visible in
+    -- increasing order and a gate polling "since N" never skips one.
+    PERFORM pg_advisory_xact_lock(hashtext('card_hotlist'));
+    INSERT INTO card_hotlist (card_id, card_number, status) VALUES (NEW.id, NEW.card_number, NEW.status);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_cards_hotlist_insert
+    AFTER INSERT ON cards
+    FOR EACH ROW EXECUTE FUNCTION card_hotlist_record();
+
+CREATE TRIGGER trg_cards_hotlist_status
+    AFTER UPDATE OF status ON cards
+    FOR EACH ROW WHEN (OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION card_hotlist_record();
+
+-- Start the hotlist from the current state of every card
+INSERT INTO card_hotlist (card_id, card_number, status)
+SELECT id, card_number, status FROM cards ORDER BY card_number;
@@ -0,0 +1,11 @@
+-- DBMS: PostgreSQL
+
+ALTER TABLE cards DROP COLUMN IF EXISTS replaced_by;
+
+-- Points from a card to the card that replaced it on renewal; the old card
+-- keeps its number for history but is blocked and emptied.
+ALTER TABLE cards ADD COLUMN replaced_by UUID;
+ALTER TABLE cards ADD CONSTRAINT fk_card_replaced_by FOREIGN KEY (replaced_by) REFERENCES cards(id) ON DELETE RESTRICT;
+
+CREATE UNIQUE INDEX idx_cards_replaced_by ON cards(replaced_by) WHERE replaced_by IS NOT NULL;
+CREATE INDEX idx_cards_expiry_active ON cards(expiry_date) WHERE status = 'active';
@@ -0,0 +1,21 @@
+-- DBMS: PostgreSQL
+
+DROP TABLE IF EXISTS card_ledger CASCADE;
+
+-- Balance movements that are not fares, e.g. moving the balance of a lost
+-- card. Every transfer writes a debit and a credit that reference each other.
+CREATE TABLE card_ledger (
+    id BIGSERIAL PRIMARY KEY,
+    card_id UUID NOT NULL,
+    entry_type VARCHAR(10) NOT NULL CHECK (entry_type IN ('debit', 'credit')),
+    amount NUMERIC(15, 2) NOT NULL CHECK (amount > 0),
+    balance_after NUMERIC(15, 2) NOT NULL CHECK (balance_after >= 0),
+    reason VARCHAR(50) NOT NULL,
+    counterpart_id BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_ledger_card FOREIGN KEY (card_id) REFERENCES cards(id) ON DELETE RESTRICT,
+    CONSTRAINT fk_ledger_counterpart FOREIGN KEY (counterpart_id) REFERENCES card_ledger(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_card_ledger_card ON card_ledger(card_id, created_at);
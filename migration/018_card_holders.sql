@@ -0,0 +1,25 @@
+-- DBMS: PostgreSQL
+
+ALTER TABLE cards DROP COLUMN IF EXISTS holder_id;
+DROP TABLE IF EXISTS card_holders CASCADE;
+
+-- Registered owners of personalised cards. The identity number is only kept
+-- as a keyed hash (CARD_HOLDER_HASH_KEY) so it can be matched but not read.
+CREATE TABLE card_holders (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    phone VARCHAR(20),
+    email VARCHAR(255),
+    id_number_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_card_holders_phone ON card_holders(phone);
+CREATE INDEX idx_card_holders_email ON card_holders(LOWER(email));
+
+-- Cards stay anonymous unless a holder is registered.
+ALTER TABLE cards ADD COLUMN holder_id UUID;
+ALTER TABLE cards ADD CONSTRAINT fk_card_holder FOREIGN KEY (holder_id) REFERENCES card_holders(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_cards_holder ON cards(holder_id) WHERE holder_id IS NOT NULL;
@@ -0,0 +1,91 @@
+-- DBMS: PostgreSQL
+
+DROP TABLE IF EXISTS alerts CASCADE;
+DROP TABLE IF EXISTS tap_queue CASCADE;
+DROP TABLE IF EXISTS tap_failures CASCADE;
+DROP FUNCTION IF EXISTS tap_queue_transaction() CASCADE;
+DROP FUNCTION IF EXISTS tap_queue_failure() CASCADE;
+ALTER TABLE transactions DROP COLUMN IF EXISTS balance_after;
+
+-- Card balance right after the tap as reported by the gate. Older rows and
+-- gates that do not report it leave it empty.
+ALTER TABLE transactions ADD COLUMN balance_after NUMERIC(15, 2);
+
+-- Taps rejected by a gate (unknown or blocked card, insufficient balance, ...).
+-- card_id is empty when the card number is not known to the system.
+CREATE TABLE tap_failures (
+    id BIGSERIAL PRIMARY KEY,
+    card_id UUID,
+    card_number VARCHAR(16),
+    gate_id UUID NOT NULL,
+    terminal_id UUID NOT NULL,
+    tap_type transaction_type NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    occurred_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_tap_failure_card FOREIGN KEY (card_id) REFERENCES cards(id) ON DELETE SET NULL,
+    CONSTRAINT fk_tap_failure_gate FOREIGN KEY (gate_id) REFERENCES gates(id) ON DELETE RESTRICT,
+    CONSTRAINT fk_tap_failure_terminal FOREIGN KEY (terminal_id) REFERENCES terminals(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_tap_failures_gate ON tap_failures(gate_id, occurred_at);
+
+-- Taps waiting for the alert engine. Triggers copy every new transaction and
+-- tap failure here; the engine deletes the rows it has evaluated in the same
+-- transaction that stores the resulting alerts.
+CREATE TABLE tap_queue (
+    id BIGSERIAL PRIMARY KEY,
+    source_id BIGINT NOT NULL,
+    accepted BOOLEAN NOT NULL,
+    card_id UUID,
+    card_number VARCHAR(16),
+    gate_id UUID NOT NULL,
+    terminal_id UUID NOT NULL,
+    tap_type transaction_type NOT NULL,
+    amount NUMERIC(15, 2),
+    balance_after NUMERIC(15, 2),
+    reason VARCHAR(50),
+    tapped_at TIMESTAMP NOT NULL
+);
+
+CREATE FUNCTION tap_queue_transaction() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO tap_queue (source_id, accepted, card_id, gate_id, terminal_id, tap_type, amount, balance_after, tapped_at)
+    VALUES (NEW.id, true, NEW.card_id, NEW.gate_id, NEW.terminal_id, NEW.transaction_type, NEW.amount, NEW.balance_after, NEW.transaction_time);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION tap_queue_failure() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO tap_queue (source_id, accepted, card_id, card_number, gate_id, terminal_id, tap_type, reason, tapped_at)
+    VALUES (NEW.id, false, NEW.card_id, NEW.card_number, NEW.gate_id, NEW.terminal_id, NEW.tap_type, NEW.reason, NEW.occurred_at);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_transactions_tap_queue
+    AFTER INSERT ON transactions
+    FOR EACH ROW EXECUTE FUNCTION tap_queue_transaction();
+
+CREATE TRIGGER trg_tap_failures_tap_queue
+    AFTER INSERT ON tap_failures
+    FOR EACH ROW EXECUTE FUNCTION tap_queue_failure();
+
+CREATE TABLE alerts (
+    id BIGSERIAL PRIMARY KEY,
+    alert_type VARCHAR(30) NOT NULL CHECK (alert_type IN ('low_balance', 'repeated_failed_taps', 'impossible_travel')),
+    card_id UUID,
+    gate_id UUID,
+    terminal_id UUID,
+    details JSONB NOT NULL DEFAULT '{}',
+    occurred_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_alert_card FOREIGN KEY (card_id) REFERENCES cards(id) ON DELETE SET NULL,
+    CONSTRAINT fk_alert_gate FOREIGN KEY (gate_id) REFERENCES gates(id) ON DELETE SET NULL,
+    CONSTRAINT fk_alert_terminal FOREIGN KEY (terminal_id) REFERENCES terminals(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_alerts_created ON alerts(created_at);
+CREATE INDEX idx_alerts_card ON alerts(card_id) WHERE card_id IS NOT NULL;
@@ -0,0 +1,7 @@
+-- DBMS: PostgreSQL
+
+DROP INDEX IF EXISTS idx_terminals_location;
+
+-- Narrows nearby lookups to the bounding box of the search radius
+CREATE INDEX idx_terminals_location ON terminals(latitude, longitude)
+    WHERE deleted_at IS NULL AND latitude IS NOT NULL;
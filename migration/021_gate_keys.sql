@@ -0,0 +1,12 @@
+-- DBMS: PostgreSQL
+
+DROP INDEX IF EXISTS idx_gates_key_hash;
+ALTER TABLE gates DROP COLUMN IF EXISTS key_hash;
+ALTER TABLE gates DROP COLUMN IF EXISTS key_issued_at;
+
+-- Gates authenticate with an API key. Only its SHA-256 is stored, and issuing
+-- a new key replaces the previous one.
+ALTER TABLE gates ADD COLUMN key_hash VARCHAR(64);
+ALTER TABLE gates ADD COLUMN key_issued_at TIMESTAMP;
+
+CREATE UNIQUE INDEX idx_gates_key_hash ON gates(key_hash) WHERE key_hash IS NOT NULL;
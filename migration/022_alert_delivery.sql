@@ -0,0 +1,62 @@
+-- DBMS: PostgreSQL
+
+DROP TABLE IF EXISTS alert_settings CASCADE;
+DROP INDEX IF EXISTS idx_tap_queue_pending;
+DROP INDEX IF EXISTS idx_alerts_undelivered;
+DROP INDEX IF EXISTS idx_alerts_failed_taps;
+ALTER TABLE tap_queue DROP COLUMN IF EXISTS attempts;
+ALTER TABLE tap_queue DROP COLUMN IF EXISTS last_error;
+ALTER TABLE alerts DROP COLUMN IF EXISTS delivered_at;
+ALTER TABLE alerts DROP COLUMN IF EXISTS delivery_attempts;
+ALTER TABLE alerts DROP COLUMN IF EXISTS last_error;
+
+-- Single row switched by the alert engine at startup. While it is off the
+-- triggers stop queueing taps, so a disabled engine does not grow the queue.
+CREATE TABLE alert_settings (
+    id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+    queue_enabled BOOLEAN NOT NULL DEFAULT true
+);
+
+INSERT INTO alert_settings DEFAULT VALUES;
+
+CREATE OR REPLACE FUNCTION tap_queue_transaction() RETURNS TRIGGER AS $$
+BEGIN
+    IF NOT (SELECT queue_enabled FROM alert_settings) THEN
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO tap_queue (source_id, accepted, card_id, gate_id, terminal_id, tap_type, amount, balance_after, tapped_at)
+    VALUES (NEW.id, true, NEW.card_id, NEW.gate_id, NEW.terminal_id, NEW.transaction_type, NEW.amount, NEW.balance_after, NEW.transaction_time);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION tap_queue_failure() RETURNS TRIGGER AS $$
+BEGIN
+    IF NOT (SELECT queue_enabled FROM alert_settings) THEN
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO tap_queue (source_id, accepted, card_id, card_number, gate_id, terminal_id, tap_type, reason, tapped_at)
+    VALUES (NEW.id, false, NEW.card_id, NEW.card_number, NEW.gate_id, NEW.terminal_id, NEW.tap_type, NEW.reason, NEW.occurred_at);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- A tap whose evaluation keeps failing stays queued with its attempts and
+-- last error once it reaches the retry limit, for an operator to inspect.
+ALTER TABLE tap_queue ADD COLUMN attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE tap_queue ADD COLUMN last_error TEXT;
+
+CREATE INDEX idx_tap_queue_pending ON tap_queue(id, attempts);
+
+-- Alerts are delivered after they are stored and retried until delivered_at
+-- is set. Alerts raised before this migration were already delivered.
+ALTER TABLE alerts ADD COLUMN delivered_at TIMESTAMP;
+ALTER TABLE alerts ADD COLUMN delivery_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE alerts ADD COLUMN last_error TEXT;
+
+UPDATE alerts SET delivered_at = created_at;
+
+CREATE INDEX idx_alerts_undelivered ON alerts(id) WHERE delivered_at IS NULL;
+CREATE INDEX idx_alerts_failed_taps ON alerts(gate_id, occurred_at) WHERE alert_type = 'repeated_failed_taps';
@@ -0,0 +1,31 @@
+-- DBMS: PostgreSQL
+
+-- The sample data in 000 was written on a dollar scale (a fare of 25.00).
+-- With IDR as the default currency those amounts become Rp25, so the sample
+-- fares, balances and fares already charged are scaled by 1000 once. Only
+-- rows of the sample terminals and cards are touched, and the marker below
+-- keeps a re-run from scaling them again.
+CREATE TABLE IF NOT EXISTS data_fixes (
+    name VARCHAR(100) PRIMARY KEY,
+    applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM data_fixes WHERE name = 'rupiah_sample_amounts') THEN
+        RETURN;
+    END IF;
+
+    UPDATE fare_matrix SET fare_amount = fare_amount * 1000
+    WHERE origin_terminal_id::text LIKE '550e8400-e29b-41d4-a716-4466554400%'
+      AND destination_terminal_id::text LIKE '550e8400-e29b-41d4-a716-4466554400%';
+
+    UPDATE cards SET balance = balance * 1000
+    WHERE id::text LIKE '770e8400-e29b-41d4-a716-4466554400%';
+
+    UPDATE transactions SET amount = amount * 1000, balance_after = balance_after * 1000
+    WHERE card_id::text LIKE '770e8400-e29b-41d4-a716-4466554400%';
+
+    INSERT INTO data_fixes (name) VALUES ('rupiah_sample_amounts');
+END;
+$$;
@@ -5,9 +5,12 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/aliffatulmf/mkp-eticket-service/internal/apperror"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/auth"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/config"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/database"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/handler"
+	"github.com/aliffatulmf/mkp-eticket-service/internal/job"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/middleware"
 	"github.com/aliffatulmf/mkp-eticket-service/internal/provider"
 
@@ -22,7 +25,10 @@ func main() {
 		panic("No .env file found")
 	}
 
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		panic("Invalid configuration: " + err.Error())
+	}
 
 	pool, err := database.Connect(context.Background(), cfg.DatabaseURL)
 	if err != nil {
@@ -31,11 +37,57 @@ func main() {
 	defer pool.Close()
 
 	jwtService := auth.NewService(cfg.JWTSecret)
+	if cfg.JWTKeyDir != "" {
+		keys, err := auth.LoadKeys(cfg.JWTKeyDir)
+		if err != nil {
+			panic("Failed to load JWT keys: " + err.Error())
+		}
+
+		jwtService, err = auth.NewKeyService(keys, cfg.JWTActiveKID)
+		if err != nil {
+			panic("Failed to initialize JWT keys: " + err.Error())
+		}
+	}
 
-	authHandler := provider.NewAuthHandler(pool, jwtService)
-	adminHandler := provider.NewAdminHandler(pool)
+	authHandler := provider.NewAuthHandler(pool, cfg, jwtService)
+	adminHandler := provider.NewAdminHandler(pool, cfg)
+	totpHandler := provider.NewTOTPHandler(pool, cfg)
+	auditHandler := provider.NewAuditHandler(pool)
 
 	terminalHandler := provider.NewTerminalHandler(pool)
+	terminalScheduleHandler := provider.NewTerminalScheduleHandler(pool, cfg)
+	lineHandler := provider.NewLineHandler(pool)
+	fareHandler := provider.NewFareHandler(pool, cfg)
+
+	cardHandler := provider.NewCardHandler(pool, cfg)
+	cardHolderHandler := provider.NewCardHolderHandler(pool, cfg)
+	hotlistHandler := provider.NewHotlistHandler(pool, cfg)
+	gateHandler := provider.NewGateHandler(pool)
+	gateService := provider.NewGateService(pool)
+	alertHandler := provider.NewAlertHandler(pool, cfg)
+
+	wellKnownHandler := handler.NewWellKnownHandler(jwtService)
+
+	cardService := provider.NewCardService(pool, cfg)
+	go job.Every(context.Background(), "card expiry", cfg.CardExpiryInterval, func(ctx context.Context) error {
+		expired, err := cardService.ExpireDue(ctx)
+		if expired > 0 {
+			log.Printf("card expiry: %d cards expired", expired)
+		}
+		return err
+	})
+
+	alertService := provider.NewAlertService(pool, cfg)
+	if err := alertService.ConfigureQueue(context.Background()); err != nil {
+		panic("Failed to configure tap alerts: " + err.Error())
+	}
+	go job.Every(context.Background(), "tap alerts", cfg.AlertInterval, func(ctx context.Context) error {
+		raised, err := alertService.ProcessQueue(ctx)
+		if raised > 0 {
+			log.Printf("tap alerts: %d alerts raised", raised)
+		}
+		return err
+	})
 
 	r := chi.NewMux()
 
@@ -45,13 +97,22 @@ func main() {
 	r.Use(chiMiddleware.RealIP)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "If-Match", "If-None-Match", middleware.GateKeyHeader},
+		ExposedHeaders:   []string{"Link", "ETag", "X-Hotlist-Version"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		apperror.Write(w, r, apperror.NotFound("route not found"))
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		apperror.Write(w, r, apperror.New(apperror.CodeNotAllowed, "method not allowed"))
+	})
+
+	r.Get("/.well-known/jwks.json", wellKnownHandler.JWKS)
+
 	r.Route("/api/v1", func(r chi.Router) {
 
 		r.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
@@ -61,23 +122,108 @@ func main() {
 
 		r.Route("/auth", func(r chi.Router) {
 			r.Post("/login", authHandler.Login)
+			r.Post("/login/totp", authHandler.LoginTOTP)
 			r.Post("/refresh", authHandler.RefreshToken)
+			r.Post("/logout", authHandler.Logout)
+		})
+
+		// Gates poll the hotlist with their own key; admins need cards:read.
+		r.Route("/hotlist", func(r chi.Router) {
+			r.Use(middleware.GateOrAdminAuth(gateService, jwtService, auth.PermCardsRead))
+
+			r.Get("/changes", hotlistHandler.Changes)
+			r.Get("/bloom", hotlistHandler.Bloom)
 		})
 
-		r.Route("/admins", func(r chi.Router) {
-			r.Post("/", adminHandler.Create)
-			r.Delete("/{id}", adminHandler.Delete)
+		// Gates check the operating hours of a terminal before accepting a
+		// tap-in with their own key; admins need terminals:read.
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.GateOrAdminAuth(gateService, jwtService, auth.PermTerminalsRead))
+
+			r.Get("/terminals/{id}/schedule", terminalScheduleHandler.Get)
+			r.Get("/terminals/{id}/status", terminalScheduleHandler.Status)
+			r.Get("/terminals/{id}/tap-in", terminalScheduleHandler.CheckTapIn)
 		})
 
+		// Gates report the taps they reject with their own key; admins need
+		// terminals:write.
+		r.With(middleware.GateOrAdminAuth(gateService, jwtService, auth.PermTerminalsWrite)).Post("/gates/{id}/tap-failures", gateHandler.ReportTapFailure)
+
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.AdminAuthMiddleware(jwtService))
 
-			r.Route("/terminals", func(r chi.Router) {
-				r.Get("/", terminalHandler.List)
-				r.Get("/{id}", terminalHandler.FindByID)
-				r.Post("/", terminalHandler.Create)
-				r.Put("/{id}", terminalHandler.Update)
-				r.Delete("/{id}", terminalHandler.Delete)
+			r.Put("/admins/me/password", adminHandler.ChangePassword)
+
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequirePasswordChanged())
+
+				r.Route("/terminals", func(r chi.Router) {
+					r.With(middleware.RequirePermission(auth.PermTerminalsRead)).Get("/", terminalHandler.List)
+					r.With(middleware.RequirePermission(auth.PermTerminalsRead)).Get("/nearby", terminalHandler.Nearby)
+					r.With(middleware.RequirePermission(auth.PermTerminalsRead)).Get("/{id}", terminalHandler.FindByID)
+					r.With(middleware.RequirePermission(auth.PermTerminalsWrite)).Post("/", terminalHandler.Create)
+					r.With(middleware.RequirePermission(auth.PermTerminalsWrite)).Put("/{id}", terminalHandler.Update)
+					r.With(middleware.RequirePermission(auth.PermTerminalsWrite)).Patch("/{id}", terminalHandler.Patch)
+					r.With(middleware.RequirePermission(auth.PermTerminalsWrite)).Delete("/{id}", terminalHandler.Delete)
+					r.With(middleware.RequirePermission(auth.PermTerminalsWrite)).Post("/{id}/restore", terminalHandler.Restore)
+					r.With(middleware.RequirePermission(auth.PermTerminalsWrite)).Put("/{id}/schedule/hours", terminalScheduleHandler.ReplaceHours)
+					r.With(middleware.RequirePermission(auth.PermTerminalsWrite)).Put("/{id}/schedule/exceptions/{date}", terminalScheduleHandler.UpsertException)
+					r.With(middleware.RequirePermission(auth.PermTerminalsWrite)).Delete("/{id}/schedule/exceptions/{date}", terminalScheduleHandler.DeleteException)
+				})
+
+				r.Route("/lines", func(r chi.Router) {
+					r.With(middleware.RequirePermission(auth.PermTerminalsRead)).Get("/", lineHandler.List)
+					r.With(middleware.RequirePermission(auth.PermTerminalsRead)).Get("/{id}", lineHandler.FindByID)
+					r.With(middleware.RequirePermission(auth.PermTerminalsWrite)).Post("/", lineHandler.Create)
+					r.With(middleware.RequirePermission(auth.PermTerminalsWrite)).Put("/{id}", lineHandler.Update)
+					r.With(middleware.RequirePermission(auth.PermTerminalsWrite)).Put("/{id}/stops", lineHandler.ReplaceStops)
+					r.With(middleware.RequirePermission(auth.PermTerminalsWrite)).Delete("/{id}", lineHandler.Delete)
+				})
+
+				r.Route("/fares", func(r chi.Router) {
+					r.With(middleware.RequirePermission(auth.PermFaresRead)).Get("/quote", fareHandler.Quote)
+					r.With(middleware.RequirePermission(auth.PermFaresRead)).Get("/zones", fareHandler.ListZones)
+					r.With(middleware.RequirePermission(auth.PermFaresWrite)).Put("/zones/{zone}", fareHandler.UpsertZone)
+					r.With(middleware.RequirePermission(auth.PermFaresRead)).Get("/zone-fares", fareHandler.ListZoneFares)
+					r.With(middleware.RequirePermission(auth.PermFaresWrite)).Put("/zone-fares/{zones}", fareHandler.UpsertZoneFare)
+					r.With(middleware.RequirePermission(auth.PermFaresWrite)).Post("/generate/preview", fareHandler.PreviewGeneration)
+					r.With(middleware.RequirePermission(auth.PermFaresWrite)).Post("/generate", fareHandler.ApplyGeneration)
+				})
+
+				r.Route("/cards", func(r chi.Router) {
+					r.With(middleware.RequirePermission(auth.PermCardsRead)).Get("/{id}", cardHandler.FindByID)
+					r.With(middleware.RequirePermission(auth.PermCardsWrite)).Put("/{id}/status", cardHandler.UpdateStatus)
+					r.With(middleware.RequirePermission(auth.PermCardsWrite)).Post("/{id}/renew", cardHandler.Renew)
+					r.With(middleware.RequirePermission(auth.PermCardsWrite)).Post("/{id}/transfer", cardHandler.TransferBalance)
+					r.With(middleware.RequirePermission(auth.PermCardsRead)).Get("/{id}/holder", cardHolderHandler.FindByCard)
+					r.With(middleware.RequirePermission(auth.PermCardsWrite)).Put("/{id}/holder", cardHolderHandler.Register)
+					r.With(middleware.RequirePermission(auth.PermCardsWrite)).Delete("/{id}/holder", cardHolderHandler.Unregister)
+				})
+
+				r.Route("/card-holders", func(r chi.Router) {
+					r.With(middleware.RequirePermission(auth.PermHoldersRead)).Post("/lookup", cardHolderHandler.Lookup)
+					r.With(middleware.RequirePermission(auth.PermCardsRead)).Get("/{id}", cardHolderHandler.FindByID)
+					r.With(middleware.RequirePermission(auth.PermCardsWrite)).Put("/{id}", cardHolderHandler.Update)
+				})
+
+				r.Route("/gates", func(r chi.Router) {
+					r.With(middleware.RequirePermission(auth.PermTerminalsWrite)).Post("/{id}/key", gateHandler.IssueKey)
+				})
+
+				r.Route("/admins", func(r chi.Router) {
+					r.Post("/me/totp", totpHandler.Enroll)
+					r.Post("/me/totp/confirm", totpHandler.Confirm)
+					r.Delete("/me/totp", totpHandler.Disable)
+					r.With(middleware.RequirePermission(auth.PermAdminsRead)).Get("/", adminHandler.List)
+					r.With(middleware.RequirePermission(auth.PermAdminsRead)).Get("/{id}", adminHandler.FindByID)
+					r.With(middleware.RequirePermission(auth.PermAdminsWrite)).Post("/", adminHandler.Create)
+					r.With(middleware.RequirePermission(auth.PermAdminsWrite)).Put("/{id}", adminHandler.Update)
+					r.With(middleware.RequirePermission(auth.PermAdminsWrite)).Delete("/{id}", adminHandler.Delete)
+					r.With(middleware.RequirePermission(auth.PermAdminsWrite)).Post("/{id}/unlock", adminHandler.Unlock)
+				})
+
+				r.With(middleware.RequirePermission(auth.PermAuditRead)).Get("/audit-logs", auditHandler.List)
+				r.With(middleware.RequirePermission(auth.PermAlertsRead)).Get("/alerts", alertHandler.List)
 			})
 		})
 	})